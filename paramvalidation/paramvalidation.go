@@ -8,6 +8,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"github.com/BioGRID/biogrid-api-common/validation"
 )
 
 // Validate that a parameter contains only the values
@@ -67,4 +68,178 @@ func StringParam( pVal, pName, defaultVal string, options []string ) (string) {
 	}
 
 	return defaultVal
-}
\ No newline at end of file
+}
+
+// SortField is a single field in a sort parameter
+// along with the direction it should be sorted in
+type SortField struct {
+	Field		string
+	Descending	bool
+}
+
+// SortFields is an ordered list of fields parsed
+// from a sort parameter, highest priority first
+type SortFields []SortField
+
+// Return the direction of the sort as used
+// by both SQL and Elasticsearch
+func (s SortField) Direction( ) (string) {
+	if s.Descending {
+		return "desc"
+	}
+
+	return "asc"
+}
+
+// Build the body of an SQL ORDER BY clause. Columns maps
+// the public field names onto database column names, any field
+// without an entry is used as is.
+func (s SortFields) SQLOrderBy( columns map[string]string ) (string) {
+	clauses := make( []string, 0, len(s) )
+	for _, sort := range s {
+		column := sort.Field
+		if mapped, ok := columns[sort.Field]; ok {
+			column = mapped
+		}
+		clauses = append( clauses, column + " " + strings.ToUpper(sort.Direction( )) )
+	}
+
+	return strings.Join( clauses, ", " )
+}
+
+// Build an Elasticsearch sort clause, ready to be
+// marshalled into the sort section of a search body
+func (s SortFields) ElasticSort( ) ([]map[string]interface{}) {
+	clauses := make( []map[string]interface{}, 0, len(s) )
+	for _, sort := range s {
+		clauses = append( clauses, map[string]interface{}{
+			sort.Field: map[string]string{ "order": sort.Direction( ) },
+		})
+	}
+
+	return clauses
+}
+
+// Validate that a parameter contains a comma separated list
+// of sortable fields, each optionally prefixed with a - for
+// descending or + for ascending, and return them in order
+func SortParam( pVal, pName string, allowed []string, defaultVal SortFields ) (SortFields, error) {
+	fields, err := splitFieldList( pVal, pName, allowed, true )
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return defaultVal, nil
+	}
+
+	sortFields := make( SortFields, 0, len(fields) )
+	for _, field := range fields {
+		sortField := SortField{ Field: field }
+		if strings.HasPrefix( field, "-" ) {
+			sortField = SortField{ Field: field[1:], Descending: true }
+		} else if strings.HasPrefix( field, "+" ) {
+			sortField = SortField{ Field: field[1:] }
+		}
+		sortFields = append( sortFields, sortField )
+	}
+
+	return sortFields, nil
+}
+
+// Validate that a parameter contains a comma separated list
+// of selectable fields and return them in order
+func FieldsParam( pVal, pName string, allowed []string, defaultVal []string ) ([]string, error) {
+	fields, err := splitFieldList( pVal, pName, allowed, false )
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return defaultVal, nil
+	}
+
+	return fields, nil
+}
+
+// Split a comma separated list of field names and verify that each
+// is in the allowed list and only appears once. When allowPrefix is set
+// a single leading + or - is permitted and ignored for these checks.
+func splitFieldList( pVal, pName string, allowed []string, allowPrefix bool ) ([]string, error) {
+	pVal = strings.TrimSpace(pVal)
+	if len(pVal) == 0 {
+		return nil, nil
+	}
+
+	valid := make( map[string]bool, len(allowed) )
+	for _, option := range allowed {
+		valid[option] = true
+	}
+
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split( pVal, "," ) {
+		field = strings.TrimSpace(field)
+		name := field
+		if allowPrefix && (strings.HasPrefix( name, "-" ) || strings.HasPrefix( name, "+" )) {
+			name = name[1:]
+		}
+
+		if len(name) == 0 {
+			return nil, errors.New( pName + ": cannot contain empty field names" )
+		}
+
+		if !valid[name] {
+			return nil, errors.New( pName + ": " + name + " is not a valid field, must be one of the following values: " + strings.Join( allowed, "," ) )
+		}
+
+		if seen[name] {
+			return nil, errors.New( pName + ": " + name + " can only be listed once" )
+		}
+
+		seen[name] = true
+		fields = append( fields, field )
+	}
+
+	return fields, nil
+}
+
+// Validate that a parameter contains an NCBI taxonomy
+// ID and return it or the default value
+func TaxIDParam( pVal, pName string, defaultVal uint64 ) (uint64, error) {
+	pVal = strings.TrimSpace(pVal)
+	if len(pVal) > 0 {
+		if !validation.IsTaxID( pVal ) {
+			return 0, errors.New( pName + ": must be a valid NCBI taxonomy ID such as 9606" )
+		}
+		return strconv.ParseUint( pVal, 10, 64 )
+	}
+
+	return defaultVal, nil
+}
+
+// Validate that a parameter contains a list of identifiers
+// separated by sep, each of which passes the isValid check such
+// as validation.IsEntrezGene, and return them with duplicates removed
+func IdentifierListParam( pVal, pName, sep string, isValid func(string) bool ) ([]string, error) {
+	pVal = strings.TrimSpace(pVal)
+	if len(pVal) == 0 {
+		return []string{}, nil
+	}
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, id := range strings.Split( pVal, sep ) {
+		id = strings.TrimSpace(id)
+		if !isValid( id ) {
+			return nil, errors.New( pName + ": " + id + " is not a valid identifier" )
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append( ids, id )
+		}
+	}
+
+	return ids, nil
+}
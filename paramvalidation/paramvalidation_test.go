@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/BioGRID/biogrid-api-common/paramvalidation"
 	"github.com/BioGRID/biogrid-api-common/testutils"
+	"github.com/BioGRID/biogrid-api-common/validation"
 )
 
 func TestParamValidation_BoolParam( t *testing.T ) {
@@ -107,4 +108,145 @@ for _,test := range tests {
 	assert.Equal( t, test.expected, result )
 }
 
-}
\ No newline at end of file
+}
+
+func TestParamValidation_SortParam( t *testing.T ) {
+
+	var allowed = []string{ "score", "officialSymbol", "id" }
+	var defaultVal = paramvalidation.SortFields{ { Field: "id" } }
+
+	var tests = []struct{
+		testDesc	string
+		pVal		string
+		expected	paramvalidation.SortFields
+		isErrorNil	bool
+	} {
+		{"Empty pVal","",defaultVal,true},
+		{"Spaces","   ",defaultVal,true},
+		{"Single ascending","score",paramvalidation.SortFields{ {"score",false} },true},
+		{"Single descending","-score",paramvalidation.SortFields{ {"score",true} },true},
+		{"Explicit ascending","+score",paramvalidation.SortFields{ {"score",false} },true},
+		{"Multiple fields","-score, officialSymbol",paramvalidation.SortFields{ {"score",true}, {"officialSymbol",false} },true},
+		{"Field not allowed","-taxId",nil,false},
+		{"Duplicate field","score,-score",nil,false},
+		{"Empty field","score,,id",nil,false},
+		{"Only prefix","-",nil,false},
+		{"Double prefix","--score",nil,false},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.testDesc )
+		result,err := paramvalidation.SortParam( test.pVal, "sort", allowed, defaultVal )
+		assert.Equal( t, test.expected, result )
+		if test.isErrorNil {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+
+}
+
+func TestParamValidation_SortFieldsClauses( t *testing.T ) {
+
+	sort := paramvalidation.SortFields{ {"score",true}, {"officialSymbol",false} }
+
+	assert.Equal( t, "score DESC, official_symbol ASC", sort.SQLOrderBy( map[string]string{ "officialSymbol": "official_symbol" } ))
+	assert.Equal( t, []map[string]interface{}{
+		{ "score": map[string]string{ "order": "desc" } },
+		{ "officialSymbol": map[string]string{ "order": "asc" } },
+	}, sort.ElasticSort( ))
+
+}
+
+func TestParamValidation_FieldsParam( t *testing.T ) {
+
+	var allowed = []string{ "id", "symbolA", "symbolB" }
+
+	var tests = []struct{
+		testDesc	string
+		pVal		string
+		expected	[]string
+		isErrorNil	bool
+	} {
+		{"Empty pVal","",[]string{ "id" },true},
+		{"Single field","symbolA",[]string{ "symbolA" },true},
+		{"Multiple fields","id, symbolA,symbolB",[]string{ "id", "symbolA", "symbolB" },true},
+		{"Field not allowed","id,taxId",nil,false},
+		{"Prefix not allowed","-id",nil,false},
+		{"Duplicate field","id,id",nil,false},
+		{"Empty field","id,",nil,false},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.testDesc )
+		result,err := paramvalidation.FieldsParam( test.pVal, "fields", allowed, []string{ "id" } )
+		assert.Equal( t, test.expected, result )
+		if test.isErrorNil {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+
+}
+
+func TestParamValidation_TaxIDParam( t *testing.T ) {
+
+	var tests = []struct{
+		testDesc	string
+		pVal		string
+		expected	uint64
+		isErrorNil	bool
+	} {
+		{"Empty pVal","",9606,true},
+		{"Valid pVal","559292",559292,true},
+		{"Valid pVal with spaces"," 10090 ",10090,true},
+		{"InValid pVal of 0","0",0,false},
+		{"InValid pVal string","human",0,false},
+		{"InValid pVal negative","-9606",0,false},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.testDesc )
+		result,err := paramvalidation.TaxIDParam( test.pVal, "taxId", 9606 )
+		assert.Equal( t, test.expected, result )
+		if test.isErrorNil {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+
+}
+
+func TestParamValidation_IdentifierListParam( t *testing.T ) {
+
+	var tests = []struct{
+		testDesc	string
+		pVal		string
+		isValid		func(string) bool
+		expected	[]string
+		isErrorNil	bool
+	} {
+		{"Empty pVal","",validation.IsEntrezGene,[]string{},true},
+		{"Valid genes","7157|672",validation.IsEntrezGene,[]string{ "7157", "672" },true},
+		{"Duplicate genes","7157|672|7157",validation.IsEntrezGene,[]string{ "7157", "672" },true},
+		{"Invalid gene","7157|TP53",validation.IsEntrezGene,nil,false},
+		{"Empty gene","7157||672",validation.IsEntrezGene,nil,false},
+		{"Valid UniProt","P04637|Q00987-2",validation.IsUniProt,[]string{ "P04637", "Q00987-2" },true},
+		{"Valid PubMed","30476227",validation.IsPubMed,[]string{ "30476227" },true},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.testDesc )
+		result,err := paramvalidation.IdentifierListParam( test.pVal, "geneList", "|", test.isValid )
+		assert.Equal( t, test.expected, result )
+		if test.isErrorNil {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"github.com/BioGRID/biogrid-api-common/validation"
+)
+
+// Maximum number of entries accepted in a batch request
+var DefaultMaxBatchItems = 1000
+
+var (
+	ErrBatchTooLarge = errors.New( "Batch contains too many entries. Split it into multiple requests." )
+	ErrBatchPartiallyInvalid = errors.New( "One or more entries in the batch failed validation." )
+	ErrBatchInvalid = errors.New( "Every entry in the batch failed validation." )
+)
+
+// BatchResult reports whether a single entry in a batch
+// was accepted, along with the issues found if it was not
+type BatchResult struct {
+	Index		int						`json:"index"`
+	Valid		bool					`json:"valid"`
+	Issues		[]validation.Issue		`json:"issues,omitempty"`
+}
+
+// Process a batch request body limited to DefaultMaxBodyBytes
+// and DefaultMaxBatchItems, see ProcessBatchWithLimit
+func ProcessBatch[T any]( r *http.Request, v *validation.ValidationHandler ) ([]T, []BatchResult, error) {
+	return ProcessBatchWithLimit[T]( r, v, DefaultMaxBodyBytes, DefaultMaxBatchItems )
+}
+
+// Process a batch request body, either a json array or newline delimited
+// json, decoding and validating each entry on its own in the same way as
+// ProcessTypedBody. Every entry gets a result, and the returned list holds
+// an item for each result, left as the zero value when the entry could not
+// be decoded. ErrBatchPartiallyInvalid is returned if some entries failed, so
+// the valid entries can still be processed and the results reported, and
+// ErrBatchInvalid if every entry failed.
+func ProcessBatchWithLimit[T any]( r *http.Request, v *validation.ValidationHandler, maxBytes int64, maxItems int ) ([]T, []BatchResult, error) {
+	mediaType, err := openBody( r, maxBytes )
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []json.RawMessage
+	switch mediaType {
+	case jsonMediaType :
+		err = decodeJSON( r.Body, &entries, nil )
+	case ndjsonMediaType :
+		entries, err = readNDJSONEntries( r.Body )
+	default :
+		err = &ContentTypeError{ ContentType: r.Header.Get( "Content-Type" ) }
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As( err, &maxBytesErr ) {
+			return nil, nil, ErrBodyTooLarge
+		}
+		return nil, nil, err
+	}
+
+	if maxItems > 0 && len(entries) > maxItems {
+		return nil, nil, ErrBatchTooLarge
+	}
+
+	items := make( []T, len(entries) )
+	results := make( []BatchResult, len(entries) )
+	for i, entry := range entries {
+		results[i] = BatchResult{ Index: i, Valid: true }
+		issues := processBatchEntry( entry, &items[i], v )
+		if len(issues) > 0 {
+			results[i].Valid = false
+			results[i].Issues = issues
+		}
+	}
+
+	switch CountValid( results ) {
+	case len(results) :
+		return items, results, nil
+	case 0 :
+		return items, results, ErrBatchInvalid
+	}
+	return items, results, ErrBatchPartiallyInvalid
+}
+
+// Decode, normalize and validate a single entry of a batch,
+// reporting problems decoding it as issues so one bad entry
+// does not prevent the rest being processed
+func processBatchEntry( entry json.RawMessage, item interface{}, v *validation.ValidationHandler ) ([]validation.Issue) {
+	value := reflect.ValueOf( item ).Elem( )
+	if err := applyDefaults( value ); err != nil {
+		return []validation.Issue{ { Rule: "default", Message: err.Error( ) } }
+	}
+
+	err := decodeJSON( bytes.NewReader( entry ), item, func( decoder *json.Decoder ) {
+		decoder.DisallowUnknownFields( )
+	})
+	if err != nil {
+		reflect.ValueOf( item ).Elem( ).Set( reflect.Zero( value.Type( )))
+		return []validation.Issue{ decodeErrorIssue( err ) }
+	}
+
+	normalizeStrings( value, "" )
+
+	if v == nil {
+		return nil
+	}
+
+	return validateBody( item, v )
+}
+
+// Describe an error decoding a batch entry as a validation issue
+func decodeErrorIssue( err error ) (validation.Issue) {
+	issue := validation.Issue{ Rule: "json", Message: err.Error( ) }
+	if issuer, ok := err.(interface{ Issues( ) []string }); ok && len(issuer.Issues( )) > 0 {
+		issue.Message = issuer.Issues( )[0]
+	}
+
+	var unknownErr *UnknownFieldError
+	var typeErr *TypeMismatchError
+	switch {
+	case errors.As( err, &unknownErr ) :
+		issue.Rule = "unknown"
+		issue.Field = unknownErr.Field
+		issue.Path = validation.JSONPointer( unknownErr.Field )
+	case errors.As( err, &typeErr ) :
+		issue.Rule = "type"
+		issue.Field = typeErr.Field
+		issue.Path = validation.JSONPointer( typeErr.Field )
+		issue.Param = typeErr.Expected
+	}
+
+	return issue
+}
+
+// Split a newline delimited json body into its entries, one per line
+func readNDJSONEntries( body io.Reader ) ([]json.RawMessage, error) {
+	entries := []json.RawMessage{}
+	err := readNDJSONLines( body, func( line []byte ) (error) {
+		var entry json.RawMessage
+		if err := decodeJSON( bytes.NewReader( line ), &entry, nil ); err != nil {
+			return err
+		}
+		entries = append( entries, entry )
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Count the entries in a list of batch results that were accepted
+func CountValid( results []BatchResult ) (int) {
+	count := 0
+	for _, result := range results {
+		if result.Valid {
+			count++
+		}
+	}
+	return count
+}
+
+// Message summarizing a list of batch results
+func BatchSummary( results []BatchResult ) (string) {
+	return strconv.Itoa( CountValid( results )) + " of " + strconv.Itoa( len(results) ) + " entries were accepted."
+}
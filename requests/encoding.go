@@ -0,0 +1,123 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrUnsupportedEncoding = errors.New( "Unsupported content encoding. Request bodies may be compressed with gzip or deflate only." )
+	ErrInvalidEncoding = errors.New( "Unable to decompress request body. Check that it matches the Content-Encoding header." )
+)
+
+// Replace the body of a request with a reader that decompresses it
+// according to the Content-Encoding header, undoing each listed encoding
+// in reverse order. The result is limited to maxBytes so a small compressed
+// body cannot expand without bound.
+func decompressBody( r *http.Request, maxBytes int64 ) (error) {
+	header := r.Header.Get( "Content-Encoding" )
+	if header == "" {
+		return nil
+	}
+
+	encodings := strings.Split( header, "," )
+	body := io.ReadCloser( r.Body )
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch strings.ToLower( strings.TrimSpace( encodings[i] )) {
+		case "identity", "" :
+			continue
+		case "gzip", "x-gzip" :
+			body, err = newGzipReader( body )
+		case "deflate" :
+			body, err = newDeflateReader( body )
+		default :
+			return ErrUnsupportedEncoding
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if maxBytes > 0 {
+		body = http.MaxBytesReader( nil, body, maxBytes )
+	}
+
+	r.Body = body
+	r.ContentLength = -1
+	r.Header.Del( "Content-Encoding" )
+	r.Header.Del( "Content-Length" )
+	return nil
+}
+
+// Open a gzip stream, reporting a bad header as an
+// encoding error rather than a problem with the body
+func newGzipReader( body io.ReadCloser ) (io.ReadCloser, error) {
+	reader, err := gzip.NewReader( body )
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As( err, &maxBytesErr ) {
+			return nil, err
+		}
+		return nil, ErrInvalidEncoding
+	}
+
+	return &decompressReader{ reader: reader, source: body }, nil
+}
+
+// Open a deflate stream, which should be zlib wrapped
+// but is often sent raw by clients, so accept either
+func newDeflateReader( body io.ReadCloser ) (io.ReadCloser, error) {
+	buffered := bufio.NewReader( body )
+	header, _ := buffered.Peek( 2 )
+
+	var reader io.ReadCloser
+	if len(header) == 2 && header[0] & 0x0f == 8 && (uint16(header[0]) << 8 | uint16(header[1])) % 31 == 0 {
+		zlibReader, err := zlib.NewReader( buffered )
+		if err != nil {
+			return nil, ErrInvalidEncoding
+		}
+		reader = zlibReader
+	} else {
+		reader = flate.NewReader( buffered )
+	}
+
+	return &decompressReader{ reader: reader, source: body }, nil
+}
+
+// decompressReader reports any failure to decompress as
+// ErrInvalidEncoding and closes the compressed body when done
+type decompressReader struct {
+	reader	io.ReadCloser
+	source	io.ReadCloser
+}
+
+func (d *decompressReader) Read( p []byte ) (int, error) {
+	n, err := d.reader.Read( p )
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As( err, &maxBytesErr ) {
+		return n, err
+	}
+
+	return n, ErrInvalidEncoding
+}
+
+func (d *decompressReader) Close( ) (error) {
+	d.reader.Close( )
+	return d.source.Close( )
+}
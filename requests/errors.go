@@ -0,0 +1,248 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The message of each of the decode errors below is the general
+// ErrInvalidJSON message, with the specifics reported by Issues
+// so they can be output in the same way as validation issues
+
+// SyntaxError reports malformed json along with where
+// in the body the problem was found
+type SyntaxError struct {
+	Offset		int64
+	Line		int
+	Column		int
+}
+
+func (e *SyntaxError) Error( ) (string) {
+	return ErrInvalidJSON.Error( )
+}
+
+func (e *SyntaxError) Unwrap( ) (error) {
+	return ErrInvalidJSON
+}
+
+func (e *SyntaxError) Issues( ) ([]string) {
+	return []string{ "syntax error at line " + strconv.Itoa( e.Line ) + ", column " + strconv.Itoa( e.Column ) + " (byte " + strconv.FormatInt( e.Offset, 10 ) + ")" }
+}
+
+// UnknownFieldError reports a field in the body
+// that does not exist in the request struct
+type UnknownFieldError struct {
+	Field		string
+}
+
+func (e *UnknownFieldError) Error( ) (string) {
+	return ErrInvalidJSON.Error( )
+}
+
+func (e *UnknownFieldError) Unwrap( ) (error) {
+	return ErrInvalidJSON
+}
+
+func (e *UnknownFieldError) Issues( ) ([]string) {
+	return []string{ e.Field + " is not a recognized field" }
+}
+
+// TypeMismatchError reports a value that could not be stored
+// in its field, such as a string sent for a numeric field
+type TypeMismatchError struct {
+	Field		string
+	Expected	string
+	Actual		string
+	Offset		int64
+}
+
+func (e *TypeMismatchError) Error( ) (string) {
+	return ErrInvalidJSON.Error( )
+}
+
+func (e *TypeMismatchError) Unwrap( ) (error) {
+	return ErrInvalidJSON
+}
+
+func (e *TypeMismatchError) Issues( ) ([]string) {
+	field := e.Field
+	if field == "" {
+		field = "request body"
+	}
+	return []string{ field + " must be " + e.Expected + ", not " + e.Actual }
+}
+
+// LineError reports a problem with a single line
+// of a newline delimited json body
+type LineError struct {
+	Line		int
+	Err			error
+}
+
+func (e *LineError) Error( ) (string) {
+	return e.Err.Error( )
+}
+
+func (e *LineError) Unwrap( ) (error) {
+	return e.Err
+}
+
+func (e *LineError) Issues( ) ([]string) {
+	prefix := "line " + strconv.Itoa( e.Line ) + ": "
+	issuer, ok := e.Err.(interface{ Issues( ) []string })
+	if !ok {
+		return []string{ prefix + e.Err.Error( ) }
+	}
+
+	issues := []string{}
+	for _, issue := range issuer.Issues( ) {
+		issues = append( issues, prefix + issue )
+	}
+	return issues
+}
+
+// EmptyBodyError reports a body with no json value in it
+type EmptyBodyError struct{}
+
+func (e *EmptyBodyError) Error( ) (string) {
+	return ErrInvalidJSON.Error( )
+}
+
+func (e *EmptyBodyError) Unwrap( ) (error) {
+	return ErrInvalidJSON
+}
+
+func (e *EmptyBodyError) Issues( ) ([]string) {
+	return []string{ "request body is empty" }
+}
+
+// ContentTypeError reports a request sent
+// with a Content-Type that is not supported
+type ContentTypeError struct {
+	ContentType	string
+}
+
+func (e *ContentTypeError) Error( ) (string) {
+	return ErrUnsupportedContentType.Error( )
+}
+
+func (e *ContentTypeError) Unwrap( ) (error) {
+	return ErrUnsupportedContentType
+}
+
+func (e *ContentTypeError) Issues( ) ([]string) {
+	return []string{ "content type " + e.ContentType + " is not supported" }
+}
+
+// Convert an error from the json decoder into one of the
+// typed errors above, using the newline positions seen while
+// reading to turn byte offsets into lines and columns
+func classifyDecodeError( err error, lines *lineCounter ) (error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As( err, &syntaxErr ) :
+		line, column := lines.position( syntaxErr.Offset )
+		return &SyntaxError{ Offset: syntaxErr.Offset, Line: line, Column: column }
+
+	case errors.Is( err, io.ErrUnexpectedEOF ) :
+		line, column := lines.position( lines.read )
+		return &SyntaxError{ Offset: lines.read, Line: line, Column: column }
+
+	case errors.Is( err, io.EOF ) :
+		return &EmptyBodyError{}
+
+	case errors.As( err, &typeErr ) :
+		return &TypeMismatchError{
+			Field: typeErr.Field,
+			Expected: describeType( typeErr.Type ),
+			Actual: typeErr.Value,
+			Offset: typeErr.Offset,
+		}
+
+	case strings.HasPrefix( err.Error( ), "json: unknown field " ) :
+		field, unquoteErr := strconv.Unquote( strings.TrimPrefix( err.Error( ), "json: unknown field " ))
+		if unquoteErr != nil {
+			field = strings.TrimPrefix( err.Error( ), "json: unknown field " )
+		}
+		return &UnknownFieldError{ Field: field }
+	}
+
+	return err
+}
+
+// Describe a Go type using json terms
+func describeType( t reflect.Type ) (string) {
+	if t == nil {
+		return "a valid value"
+	}
+
+	switch t.Kind( ) {
+	case reflect.String :
+		return "a string"
+	case reflect.Bool :
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64 :
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64 :
+		return "a positive integer"
+	case reflect.Float32, reflect.Float64 :
+		return "a number"
+	case reflect.Slice, reflect.Array :
+		return "an array"
+	case reflect.Map, reflect.Struct :
+		return "an object"
+	case reflect.Ptr :
+		return describeType( t.Elem( ))
+	}
+
+	return "a valid value"
+}
+
+// lineCounter records where each newline falls in the bytes
+// written to it, so offsets can be reported as lines and columns
+// without keeping a copy of the body
+type lineCounter struct {
+	read		int64
+	newlines	[]int64
+}
+
+func (l *lineCounter) Write( p []byte ) (int, error) {
+	for i, b := range p {
+		if b == '\n' {
+			l.newlines = append( l.newlines, l.read + int64(i) )
+		}
+	}
+	l.read += int64(len(p))
+	return len(p), nil
+}
+
+// Get the one based line and column of the byte before offset,
+// which is where the json decoder reports errors
+func (l *lineCounter) position( offset int64 ) (int, int) {
+	last := offset - 1
+	if last < 0 {
+		last = 0
+	}
+
+	line := sort.Search( len(l.newlines), func( i int ) bool {
+		return l.newlines[i] >= last
+	})
+
+	lineStart := int64(0)
+	if line > 0 {
+		lineStart = l.newlines[line-1] + 1
+	}
+
+	return line + 1, int(last - lineStart) + 1
+}
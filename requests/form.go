@@ -0,0 +1,237 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Maximum size of a single uploaded file, in bytes
+var DefaultMaxFileBytes int64 = 5 << 20
+
+// Maximum amount of a multipart body, in bytes, held in
+// memory before the remainder is written to temporary files
+var MultipartMemoryBytes int64 = 8 << 20
+
+var (
+	ErrFileTooLarge = errors.New( "Uploaded file is too large. Reduce the size of the file or split it into multiple requests." )
+	ErrInvalidForm = errors.New( "Incorrectly formatted form in request. Check specifications for correct form fields." )
+)
+
+// Decode a url encoded form body into the fields of a struct
+func decodeFormBody( r *http.Request, d interface{} ) (error) {
+	body, err := io.ReadAll( r.Body )
+	if err != nil {
+		return err
+	}
+
+	values, err := url.ParseQuery( string(body) )
+	if err != nil {
+		return ErrInvalidForm
+	}
+
+	return decodeValues( values, d )
+}
+
+// Decode a multipart form body into the fields of a struct. Uploaded
+// files are read into the field matching their part name, with each
+// line of the file becoming an entry when the field is a slice.
+func decodeMultipartBody( r *http.Request, d interface{} ) (error) {
+	err := r.ParseMultipartForm( MultipartMemoryBytes )
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As( err, &maxBytesErr ) {
+			return err
+		}
+		return ErrInvalidForm
+	}
+	defer r.MultipartForm.RemoveAll( )
+
+	values := url.Values{}
+	for name, fieldValues := range r.MultipartForm.Value {
+		values[name] = append( values[name], fieldValues... )
+	}
+
+	for name, files := range r.MultipartForm.File {
+		for _, file := range files {
+			fileValues, err := readUploadedFile( file )
+			if err != nil {
+				return err
+			}
+			values[name] = append( values[name], fileValues... )
+		}
+	}
+
+	return decodeValues( values, d )
+}
+
+// Read an uploaded file into a list of its
+// non-empty lines with whitespace trimmed
+func readUploadedFile( file *multipart.FileHeader ) ([]string, error) {
+	if file.Size > DefaultMaxFileBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	f, err := file.Open( )
+	if err != nil {
+		return nil, ErrInvalidForm
+	}
+	defer f.Close( )
+
+	content, err := io.ReadAll( io.LimitReader( f, DefaultMaxFileBytes + 1 ))
+	if err != nil {
+		return nil, ErrInvalidForm
+	}
+
+	if int64(len(content)) > DefaultMaxFileBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split( string(content), "\n" ) {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append( lines, line )
+		}
+	}
+
+	return lines, nil
+}
+
+// Set the fields of the struct d points to from form values, matching
+// names against the form tag, then the json tag, then the field name
+func decodeValues( values url.Values, d interface{} ) (error) {
+	target := reflect.ValueOf( d )
+	for target.Kind( ) == reflect.Ptr || target.Kind( ) == reflect.Interface {
+		if target.IsNil( ) {
+			return ErrInvalidForm
+		}
+		target = target.Elem( )
+	}
+
+	if target.Kind( ) != reflect.Struct {
+		return ErrInvalidForm
+	}
+
+	fields := formFields( target.Type( ))
+	for name, fieldValues := range values {
+		index, ok := fields[name]
+		if !ok {
+			return &UnknownFieldError{ Field: name }
+		}
+
+		err := setFormField( target.Field( index ), name, fieldValues )
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Map the form names of each exported field to its index
+func formFields( t reflect.Type ) (map[string]int) {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField( ); i++ {
+		fld := t.Field( i )
+		if fld.PkgPath != "" {
+			continue
+		}
+
+		name := strings.SplitN( fld.Tag.Get( "form" ), ",", 2 )[0]
+		if name == "" {
+			name = strings.SplitN( fld.Tag.Get( "json" ), ",", 2 )[0]
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = fld.Name
+		}
+
+		fields[name] = i
+	}
+
+	return fields
+}
+
+// Set a field from its form values, filling every entry of a slice
+// or using the last value given for any other type of field
+func setFormField( field reflect.Value, name string, values []string ) (error) {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if field.Kind( ) == reflect.Ptr {
+		if field.IsNil( ) {
+			field.Set( reflect.New( field.Type( ).Elem( )))
+		}
+		return setFormField( field.Elem( ), name, values )
+	}
+
+	if field.Kind( ) == reflect.Slice {
+		slice := reflect.MakeSlice( field.Type( ), len(values), len(values) )
+		for i, value := range values {
+			err := setFormValue( slice.Index( i ), name, value )
+			if err != nil {
+				return err
+			}
+		}
+		field.Set( slice )
+		return nil
+	}
+
+	return setFormValue( field, name, values[len(values)-1] )
+}
+
+// Parse a single form value into a field
+func setFormValue( field reflect.Value, name, value string ) (error) {
+	mismatch := &TypeMismatchError{ Field: name, Expected: describeType( field.Type( )), Actual: strconv.Quote( value ) }
+
+	switch field.Kind( ) {
+	case reflect.String :
+		field.SetString( value )
+	case reflect.Bool :
+		b, err := strconv.ParseBool( value )
+		if err != nil {
+			return mismatch
+		}
+		field.SetBool( b )
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64 :
+		i, err := strconv.ParseInt( value, 10, field.Type( ).Bits( ))
+		if err != nil {
+			return mismatch
+		}
+		field.SetInt( i )
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64 :
+		u, err := strconv.ParseUint( value, 10, field.Type( ).Bits( ))
+		if err != nil {
+			return mismatch
+		}
+		field.SetUint( u )
+	case reflect.Float32, reflect.Float64 :
+		f, err := strconv.ParseFloat( value, field.Type( ).Bits( ))
+		if err != nil {
+			return mismatch
+		}
+		field.SetFloat( f )
+	case reflect.Ptr :
+		if field.IsNil( ) {
+			field.Set( reflect.New( field.Type( ).Elem( )))
+		}
+		return setFormValue( field.Elem( ), name, value )
+	default :
+		return mismatch
+	}
+
+	return nil
+}
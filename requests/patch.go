@@ -0,0 +1,524 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"github.com/BioGRID/biogrid-api-common/validation"
+)
+
+var (
+	ErrPatchFailed = errors.New( "Unable to apply patch. Check that each operation is valid for the current record." )
+	ErrPatchTarget = errors.New( "Patches can only be applied to a pointer to a struct." )
+)
+
+// PatchError reports the operation in a JSON Patch that could not be
+// applied. The whole patch is rejected when any operation fails. Each
+// operation works on the result of the ones before it, so applying stops
+// at the first failure and only that operation is reported.
+type PatchError struct {
+	Index		int
+	Op			string
+	Path		string
+	Reason		string
+}
+
+func (e *PatchError) Error( ) (string) {
+	return ErrPatchFailed.Error( )
+}
+
+func (e *PatchError) Unwrap( ) (error) {
+	return ErrPatchFailed
+}
+
+func (e *PatchError) Issues( ) ([]string) {
+	return []string{ "operation " + strconv.Itoa( e.Index ) + " (" + e.Op + " " + e.Path + "): " + e.Reason }
+}
+
+// PatchOperation is a single operation in a JSON Patch
+// document, with nil pointers for members that were not sent
+type PatchOperation struct {
+	Op			string				`json:"op"`
+	Path		*string				`json:"path"`
+	From		*string				`json:"from"`
+	Value		*json.RawMessage	`json:"value"`
+}
+
+// Apply a JSON Merge Patch limited to DefaultMaxBodyBytes
+func ProcessMergePatch( r *http.Request, d interface{}, v *validation.ValidationHandler ) ([]validation.Issue, error) {
+	return ProcessMergePatchWithLimit( r, d, v, DefaultMaxBodyBytes )
+}
+
+// Apply an RFC 7396 JSON Merge Patch from the request body to the
+// struct d points to, then validate the result if a validation handler
+// is given. d is only changed when the patch applies and validates.
+// A maxBytes of zero or less means no limit.
+func ProcessMergePatchWithLimit( r *http.Request, d interface{}, v *validation.ValidationHandler, maxBytes int64 ) ([]validation.Issue, error) {
+	var patch interface{}
+	err := readPatchBody( r, &patch, maxBytes )
+	if err != nil {
+		return nil, err
+	}
+
+	return applyPatchedDocument( d, v, func( doc interface{} ) (interface{}, error) {
+		return MergePatch( doc, patch ), nil
+	})
+}
+
+// Apply a JSON Patch limited to DefaultMaxBodyBytes
+func ProcessJSONPatch( r *http.Request, d interface{}, v *validation.ValidationHandler ) ([]validation.Issue, error) {
+	return ProcessJSONPatchWithLimit( r, d, v, DefaultMaxBodyBytes )
+}
+
+// Apply an RFC 6902 JSON Patch from the request body to the struct d
+// points to, then validate the result if a validation handler is given.
+// d is only changed when every operation applies and the result validates,
+// otherwise the first failed operation is returned as a PatchError.
+// A maxBytes of zero or less means no limit.
+func ProcessJSONPatchWithLimit( r *http.Request, d interface{}, v *validation.ValidationHandler, maxBytes int64 ) ([]validation.Issue, error) {
+	var operations []PatchOperation
+	err := readPatchBody( r, &operations, maxBytes )
+	if err != nil {
+		return nil, err
+	}
+
+	return applyPatchedDocument( d, v, func( doc interface{} ) (interface{}, error) {
+		return JSONPatch( doc, operations )
+	})
+}
+
+// MergePatch applies an RFC 7396 merge patch to a document
+// decoded from json, returning the patched document
+func MergePatch( doc, patch interface{} ) (interface{}) {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	docObject, ok := doc.(map[string]interface{})
+	if !ok {
+		docObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete( docObject, key )
+		} else {
+			docObject[key] = MergePatch( docObject[key], value )
+		}
+	}
+
+	return docObject
+}
+
+// JSONPatch applies RFC 6902 operations to a document decoded from
+// json, stopping at the first operation that cannot be applied
+func JSONPatch( doc interface{}, operations []PatchOperation ) (interface{}, error) {
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return nil, &PatchError{ Index: i, Op: operation.Op, Reason: "path is required" }
+		}
+
+		path := *operation.Path
+		fail := func( reason string ) (error) {
+			return &PatchError{ Index: i, Op: operation.Op, Path: path, Reason: reason }
+		}
+
+		tokens, err := parsePointer( path )
+		if err != nil {
+			return nil, fail( err.Error( ))
+		}
+
+		var value interface{}
+		switch operation.Op {
+		case "add", "replace", "test" :
+			if operation.Value == nil {
+				return nil, fail( "value is required" )
+			}
+			value, err = decodePatchValue( *operation.Value )
+			if err != nil {
+				return nil, fail( "value is not valid json" )
+			}
+		case "move", "copy" :
+			if operation.From == nil {
+				return nil, fail( "from is required" )
+			}
+			from, err := parsePointer( *operation.From )
+			if err != nil {
+				return nil, fail( err.Error( ))
+			}
+			if operation.Op == "move" && len(from) < len(tokens) && reflect.DeepEqual( from, tokens[:len(from)] ) {
+				return nil, fail( "cannot move a value into one of its own children" )
+			}
+			value, err = getPointer( doc, from )
+			if err != nil {
+				return nil, fail( err.Error( ))
+			}
+			if operation.Op == "move" {
+				doc, err = removePointer( doc, from )
+			} else {
+				value = copyDocument( value )
+			}
+			if err != nil {
+				return nil, fail( err.Error( ))
+			}
+		case "remove" :
+		default :
+			return nil, fail( "unknown operation" )
+		}
+
+		switch operation.Op {
+		case "add", "move", "copy" :
+			doc, err = addPointer( doc, tokens, value )
+		case "remove" :
+			doc, err = removePointer( doc, tokens )
+		case "replace" :
+			_, err = getPointer( doc, tokens )
+			if err == nil {
+				doc, err = setPointer( doc, tokens, value, false )
+			}
+		case "test" :
+			var current interface{}
+			current, err = getPointer( doc, tokens )
+			if err == nil && !documentsEqual( current, value ) {
+				err = errors.New( "value does not match" )
+			}
+		}
+
+		if err != nil {
+			return nil, fail( err.Error( ))
+		}
+	}
+
+	return doc, nil
+}
+
+// Read the body of a patch request, which must be json
+func readPatchBody( r *http.Request, patch interface{}, maxBytes int64 ) (error) {
+	mediaType, err := openBody( r, maxBytes )
+	if err != nil {
+		return err
+	}
+
+	if mediaType != jsonMediaType {
+		return &ContentTypeError{ ContentType: r.Header.Get( "Content-Type" ) }
+	}
+
+	err = decodeJSON( r.Body, patch, func( decoder *json.Decoder ) {
+		decoder.UseNumber( )
+	})
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As( err, &maxBytesErr ) {
+		return ErrBodyTooLarge
+	}
+
+	return err
+}
+
+// Convert the struct d points to into a json document, change it
+// with apply, then decode the result into a copy of d to validate it
+// before replacing d. Fields without a json name keep their values.
+func applyPatchedDocument( d interface{}, v *validation.ValidationHandler, apply func( interface{} ) (interface{}, error) ) ([]validation.Issue, error) {
+	target := reflect.ValueOf( d )
+	if target.Kind( ) != reflect.Ptr || target.IsNil( ) || target.Elem( ).Kind( ) != reflect.Struct {
+		return nil, ErrPatchTarget
+	}
+
+	original, err := json.Marshal( d )
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := decodePatchValue( original )
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = apply( doc )
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := json.Marshal( doc )
+	if err != nil {
+		return nil, err
+	}
+
+	// Start from the current values so fields json does not
+	// see are kept, but clear the rest so removed keys are zeroed
+	result := reflect.New( target.Elem( ).Type( ))
+	result.Elem( ).Set( target.Elem( ))
+	for _, index := range jsonFields( result.Elem( ).Type( )) {
+		field := result.Elem( ).Field( index )
+		field.Set( reflect.Zero( field.Type( )))
+	}
+
+	err = decodeJSON( bytes.NewReader( patched ), result.Interface( ), func( decoder *json.Decoder ) {
+		decoder.DisallowUnknownFields( )
+	})
+	if err != nil {
+		// Offsets are into the patched record, not the request body
+		var typeErr *TypeMismatchError
+		if errors.As( err, &typeErr ) {
+			typeErr.Offset = 0
+		}
+		return nil, err
+	}
+
+	if v != nil {
+		issues := validateBody( result.Interface( ), v )
+		if len(issues) > 0 {
+			return issues, ErrFailedValidation
+		}
+	}
+
+	target.Elem( ).Set( result.Elem( ))
+	return nil, nil
+}
+
+// Find the indexes of the fields of a struct type that json
+// encodes, skipping unexported fields and fields tagged json:"-"
+func jsonFields( t reflect.Type ) ([]int) {
+	fields := []int{}
+	for i := 0; i < t.NumField( ); i++ {
+		fld := t.Field( i )
+		if fld.PkgPath != "" {
+			continue
+		}
+
+		// A tag of "-," names a field "-" rather than skipping it
+		if fld.Tag.Get( "json" ) == "-" {
+			continue
+		}
+
+		fields = append( fields, i )
+	}
+
+	return fields
+}
+
+// Decode a json value keeping numbers exact
+func decodePatchValue( raw []byte ) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder( bytes.NewReader( raw ))
+	decoder.UseNumber( )
+	err := decoder.Decode( &value )
+	return value, err
+}
+
+// Split an RFC 6901 pointer into its unescaped tokens
+func parsePointer( pointer string ) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix( pointer, "/" ) {
+		return nil, errors.New( "path must be empty or start with /" )
+	}
+
+	tokens := strings.Split( pointer[1:], "/" )
+	for i, token := range tokens {
+		tokens[i] = strings.Replace( strings.Replace( token, "~1", "/", -1 ), "~0", "~", -1 )
+	}
+
+	return tokens, nil
+}
+
+// Get the value a pointer refers to
+func getPointer( doc interface{}, tokens []string ) (interface{}, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case map[string]interface{} :
+			value, ok := container[token]
+			if !ok {
+				return nil, errors.New( "path does not exist" )
+			}
+			doc = value
+		case []interface{} :
+			index, err := arrayIndex( token, len(container) - 1 )
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default :
+			return nil, errors.New( "path does not exist" )
+		}
+	}
+
+	return doc, nil
+}
+
+// Add a value at a pointer, inserting into arrays
+// rather than replacing the entry at the index
+func addPointer( doc interface{}, tokens []string, value interface{} ) (interface{}, error) {
+	return setPointer( doc, tokens, value, true )
+}
+
+// Set the value at a pointer, returning the updated document. When
+// insert is set, array entries are inserted and - appends to the array.
+func setPointer( doc interface{}, tokens []string, value interface{}, insert bool ) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return modifyParent( doc, tokens, func( parent interface{}, token string ) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{} :
+			container[token] = value
+			return container, nil
+		case []interface{} :
+			if !insert {
+				index, err := arrayIndex( token, len(container) - 1 )
+				if err != nil {
+					return nil, err
+				}
+				container[index] = value
+				return container, nil
+			}
+			index := len(container)
+			if token != "-" {
+				var err error
+				index, err = arrayIndex( token, len(container) )
+				if err != nil {
+					return nil, err
+				}
+			}
+			container = append( container, nil )
+			copy( container[index+1:], container[index:] )
+			container[index] = value
+			return container, nil
+		}
+		return nil, errors.New( "path does not exist" )
+	})
+}
+
+// Remove the value at a pointer
+func removePointer( doc interface{}, tokens []string ) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New( "cannot remove the whole document" )
+	}
+
+	return modifyParent( doc, tokens, func( parent interface{}, token string ) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{} :
+			if _, ok := container[token]; !ok {
+				return nil, errors.New( "path does not exist" )
+			}
+			delete( container, token )
+			return container, nil
+		case []interface{} :
+			index, err := arrayIndex( token, len(container) - 1 )
+			if err != nil {
+				return nil, err
+			}
+			return append( container[:index], container[index+1:]... ), nil
+		}
+		return nil, errors.New( "path does not exist" )
+	})
+}
+
+// Walk to the parent of the last token and replace it with the result
+// of change, rebuilding each container on the way back up since arrays
+// may be reallocated
+func modifyParent( doc interface{}, tokens []string, change func( interface{}, string ) (interface{}, error) ) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change( doc, tokens[0] )
+	}
+
+	child, err := getPointer( doc, tokens[:1] )
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = modifyParent( child, tokens[1:], change )
+	if err != nil {
+		return nil, err
+	}
+
+	return setPointer( doc, tokens[:1], child, false )
+}
+
+// Parse an array index token, which must be a number without
+// leading zeros that is no larger than max
+func arrayIndex( token string, max int ) (int, error) {
+	index, err := strconv.Atoi( token )
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New( "path has an invalid array index " + token )
+	}
+
+	if index > max {
+		return 0, errors.New( "path has an array index out of range " + token )
+	}
+
+	return index, nil
+}
+
+// Make a deep copy of a document so copied
+// values can be changed independently
+func copyDocument( doc interface{} ) (interface{}) {
+	switch value := doc.(type) {
+	case map[string]interface{} :
+		copied := make( map[string]interface{}, len(value) )
+		for key, entry := range value {
+			copied[key] = copyDocument( entry )
+		}
+		return copied
+	case []interface{} :
+		copied := make( []interface{}, len(value) )
+		for i, entry := range value {
+			copied[i] = copyDocument( entry )
+		}
+		return copied
+	}
+
+	return doc
+}
+
+// Compare two documents, treating numbers as
+// equal when they have the same value
+func documentsEqual( a, b interface{} ) (bool) {
+	aNumber, aOk := a.(json.Number)
+	bNumber, bOk := b.(json.Number)
+	if aOk && bOk {
+		aFloat, aErr := aNumber.Float64( )
+		bFloat, bErr := bNumber.Float64( )
+		return aErr == nil && bErr == nil && aFloat == bFloat
+	}
+
+	switch aValue := a.(type) {
+	case map[string]interface{} :
+		bValue, ok := b.(map[string]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for key, entry := range aValue {
+			other, ok := bValue[key]
+			if !ok || !documentsEqual( entry, other ) {
+				return false
+			}
+		}
+		return true
+	case []interface{} :
+		bValue, ok := b.([]interface{})
+		if !ok || len(aValue) != len(bValue) {
+			return false
+		}
+		for i := range aValue {
+			if !documentsEqual( aValue[i], bValue[i] ) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual( a, b )
+}
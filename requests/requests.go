@@ -6,41 +6,346 @@ package requests
 
 import (
 	"net/http"
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
 	"github.com/BioGRID/biogrid-api-common/validation"
 )
 
+// Media types accepted for request bodies
+const (
+	jsonMediaType = "application/json"
+	ndjsonMediaType = "application/ndjson"
+	formMediaType = "application/x-www-form-urlencoded"
+	multipartMediaType = "multipart/form-data"
+)
+
+// Maximum size of a request body, in bytes, used by ProcessBody
+var DefaultMaxBodyBytes int64 = 10 << 20
+
+var (
+	ErrNoBody = errors.New( "Unable to process request without body." )
+	ErrBodyTooLarge = errors.New( "Request body is too large. Reduce the size of the request or split it into multiple requests." )
+	ErrTrailingData = errors.New( "Request body must contain only a single JSON value." )
+	ErrInvalidJSON = errors.New( "Incorrectly formatted json in request. Check specifications for correct JSON request body." )
+	ErrUnsupportedContentType = errors.New( "Unsupported content type. Check specifications for the content types accepted by this request." )
+	ErrFailedValidation = errors.New( "Request failed validation." )
+)
+
+// Process a request body limited to DefaultMaxBodyBytes
 func ProcessBody( r *http.Request, d interface{}, v *validation.ValidationHandler ) ([]string,error) {
+	return ProcessBodyWithLimit( r, d, v, DefaultMaxBodyBytes )
+}
+
+// Process a request body, failing with ErrBodyTooLarge if it
+// is larger than maxBytes. A maxBytes of zero or less means no limit.
+func ProcessBodyWithLimit( r *http.Request, d interface{}, v *validation.ValidationHandler, maxBytes int64 ) ([]string,error) {
 
 	issues := []string{}
 
-	// Check body exists
-	if r.Body == nil {
-		return issues, errors.New( "Unable to process request without body." )
+	validationIssues, err := processBody( r, d, v, maxBytes, nil )
+	if len(validationIssues) > 0 {
+		issues = validation.Messages( validationIssues )
+	}
+
+	return issues, err
+}
+
+// Decode a request body into d, which must be a pointer, run
+// prepare over the result if given, then validate it if a
+// validation handler is given
+func processBody( r *http.Request, d interface{}, v *validation.ValidationHandler, maxBytes int64, prepare func( interface{} ) ) ([]validation.Issue, error) {
+
+	mediaType, err := openBody( r, maxBytes )
+	if err != nil {
+		return nil, err
 	}
 
-	// Pull data out of JSON request body
-	err := decodeJSONBody( r, d)
+	// Pull data out of the request body
+	switch mediaType {
+	case formMediaType :
+		err = decodeFormBody( r, d )
+	case multipartMediaType :
+		err = decodeMultipartBody( r, d )
+	case ndjsonMediaType :
+		err = decodeNDJSONBody( r, d )
+	default :
+		err = decodeJSONBody( r, d )
+	}
 	if err != nil {
-		return issues, errors.New( "Incorrectly formatted json in request. Check specifications for correct JSON request body." )
+		var maxBytesErr *http.MaxBytesError
+		if errors.As( err, &maxBytesErr ) {
+			return nil, ErrBodyTooLarge
+		}
+		return nil, err
+	}
+
+	if prepare != nil {
+		prepare( d )
 	}
 
 	// Perform validation
 	if v != nil {
-		issues := v.ValidateStruct(d)
+		issues := validateBody( d, v )
 		if len(issues) > 0 {
-			return issues, errors.New( "Request failed validation." )
+			return issues, ErrFailedValidation
 		}
 	}
 
-	return issues, nil
+	return nil, nil
+}
+
+// Check a request has a body of a supported type, then limit it
+// to maxBytes and decompress it, returning its media type
+func openBody( r *http.Request, maxBytes int64 ) (string, error) {
+
+	// Check body exists
+	if r.Body == nil {
+		return "", ErrNoBody
+	}
+
+	mediaType, err := bodyMediaType( r )
+	if err != nil {
+		return "", err
+	}
+
+	if maxBytes > 0 {
+		if r.ContentLength > maxBytes {
+			return "", ErrBodyTooLarge
+		}
+		r.Body = http.MaxBytesReader( nil, r.Body, maxBytes )
+	}
+
+	err = decompressBody( r, maxBytes )
+	if err != nil {
+		return "", err
+	}
+
+	return mediaType, nil
+}
+
+// Validate a decoded body, validating each entry separately
+// when the body is a list and prefixing its issues with the index.
+// Only structs carry validation rules, so anything else is skipped.
+func validateBody( d interface{}, v *validation.ValidationHandler ) ([]validation.Issue) {
+	value := reflect.ValueOf( d )
+	for value.Kind( ) == reflect.Ptr && !value.IsNil( ) && value.Elem( ).Kind( ) == reflect.Ptr {
+		value = value.Elem( )
+	}
+
+	list := reflect.Indirect( value )
+	if list.Kind( ) != reflect.Slice {
+		if !isStruct( value ) {
+			return nil
+		}
+		return v.ValidateStructDetailed( value.Interface( ))
+	}
+
+	var issues []validation.Issue
+	for i := 0; i < list.Len( ); i++ {
+		if !isStruct( list.Index( i )) {
+			continue
+		}
+		index := strconv.Itoa( i )
+		for _, issue := range v.ValidateStructDetailed( list.Index( i ).Interface( )) {
+			issue.Path = "/" + index + issue.Path
+			issue.Message = "[" + index + "] " + issue.Message
+			issues = append( issues, issue )
+		}
+	}
+
+	return issues
+}
+
+// Check if a value is a struct or a non nil pointer to one
+func isStruct( value reflect.Value ) (bool) {
+	for value.Kind( ) == reflect.Ptr {
+		if value.IsNil( ) {
+			return false
+		}
+		value = value.Elem( )
+	}
+	return value.Kind( ) == reflect.Struct
+}
+
+// Get the HTTP status code that best describes
+// an error returned while processing a body
+func ErrorStatus( err error ) (int) {
+	if errors.Is( err, ErrBodyTooLarge ) || errors.Is( err, ErrFileTooLarge ) || errors.Is( err, ErrBatchTooLarge ) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	if errors.Is( err, ErrUnsupportedContentType ) || errors.Is( err, ErrUnsupportedEncoding ) {
+		return http.StatusUnsupportedMediaType
+	}
+
+	if errors.Is( err, ErrBatchPartiallyInvalid ) {
+		return http.StatusMultiStatus
+	}
+
+	if errors.Is( err, ErrBatchInvalid ) {
+		return http.StatusUnprocessableEntity
+	}
+
+	// A failed test operation means the record is not in the
+	// state the client expected, anything else could not be applied
+	var patchErr *PatchError
+	if errors.As( err, &patchErr ) {
+		if patchErr.Op == "test" {
+			return http.StatusConflict
+		}
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusBadRequest
+}
+
+// Find the media type of a request body, reducing any json based type
+// such as application/merge-patch+json to application/json. A missing
+// content type is treated as json.
+func bodyMediaType( r *http.Request ) (string, error) {
+	contentType := r.Header.Get( "Content-Type" )
+	if contentType == "" {
+		return jsonMediaType, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType( contentType )
+	if err != nil {
+		return "", &ContentTypeError{ ContentType: contentType }
+	}
+
+	switch {
+	case mediaType == jsonMediaType || strings.HasSuffix( mediaType, "+json" ) :
+		return jsonMediaType, nil
+	case mediaType == "application/jsonl" || mediaType == "application/x-ndjson" :
+		return ndjsonMediaType, nil
+	case mediaType == formMediaType || mediaType == multipartMediaType || mediaType == ndjsonMediaType :
+		return mediaType, nil
+	}
+
+	return "", &ContentTypeError{ ContentType: contentType }
 }
 
 // Decode the json body of a request
 func decodeJSONBody( r *http.Request, d interface{} ) (error) {
-	decoder := json.NewDecoder( r.Body )
-	decoder.DisallowUnknownFields( )
-	err := decoder.Decode( &d )
-	return err
-}
\ No newline at end of file
+	return decodeJSON( r.Body, d, func( decoder *json.Decoder ) {
+		decoder.DisallowUnknownFields( )
+	})
+}
+
+// Decode a single json value from body, with configure
+// setting any options needed on the decoder
+func decodeJSON( body io.Reader, d interface{}, configure func( *json.Decoder ) ) (error) {
+	lines := &lineCounter{}
+	decoder := json.NewDecoder( io.TeeReader( body, lines ))
+	if configure != nil {
+		configure( decoder )
+	}
+	err := decoder.Decode( d )
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As( err, &maxBytesErr ) {
+			return err
+		}
+		return classifyDecodeError( err, lines )
+	}
+
+	// Anything other than whitespace after
+	// the first value is an error
+	_, err = decoder.Token( )
+	if err == io.EOF {
+		return nil
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As( err, &maxBytesErr ) || errors.Is( err, ErrInvalidEncoding ) {
+		return err
+	}
+
+	return ErrTrailingData
+}
+
+// Decode a newline delimited json body, where each line is a
+// separate json value, into the slice that d points to. Blank
+// lines are skipped, and errors report the line they were found on.
+func decodeNDJSONBody( r *http.Request, d interface{} ) (error) {
+	list := reflect.ValueOf( d )
+	if list.Kind( ) != reflect.Ptr || list.Elem( ).Kind( ) != reflect.Slice {
+		return errors.New( "Newline delimited json can only be decoded into a pointer to a slice." )
+	}
+	list = list.Elem( )
+
+	items := reflect.MakeSlice( list.Type( ), 0, 0 )
+	err := readNDJSONLines( r.Body, func( line []byte ) (error) {
+		item := reflect.New( list.Type( ).Elem( ))
+		err := decodeJSON( bytes.NewReader( line ), item.Interface( ), func( decoder *json.Decoder ) {
+			decoder.DisallowUnknownFields( )
+		})
+		if err != nil {
+			return err
+		}
+		items = reflect.Append( items, item.Elem( ))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	list.Set( items )
+	return nil
+}
+
+// Read a newline delimited json body one line at a time, passing each
+// non blank line to decode and placing any error it returns at that line
+func readNDJSONLines( body io.Reader, decode func( line []byte ) error ) (error) {
+	reader := bufio.NewReader( body )
+	offset := int64(0)
+	decoded := 0
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := reader.ReadBytes( '\n' )
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		lineStart := offset
+		offset += int64(len(line))
+
+		if len(bytes.TrimSpace( line )) > 0 {
+			if err := decode( line ); err != nil {
+				return lineDecodeError( err, lineNumber, lineStart )
+			}
+			decoded++
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if decoded == 0 {
+		return &EmptyBodyError{}
+	}
+	return nil
+}
+
+// Place an error decoding a single line of a newline delimited
+// json body at that line of the body
+func lineDecodeError( err error, lineNumber int, lineStart int64 ) (error) {
+	var syntaxErr *SyntaxError
+	if errors.As( err, &syntaxErr ) {
+		return &SyntaxError{ Offset: lineStart + syntaxErr.Offset, Line: lineNumber, Column: syntaxErr.Column }
+	}
+
+	var typeErr *TypeMismatchError
+	if errors.As( err, &typeErr ) {
+		typeErr.Offset += lineStart
+	}
+
+	return &LineError{ Line: lineNumber, Err: err }
+}
@@ -8,6 +8,13 @@ import (
 	"testing"
 	"net/http"
 	"bytes"
+	"errors"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime/multipart"
+	"strings"
 	"github.com/stretchr/testify/assert"
 	"github.com/BioGRID/biogrid-api-common/requests"
 	"github.com/BioGRID/biogrid-api-common/testutils"
@@ -108,4 +115,620 @@ func TestRegister_JSONBodyValidation( t *testing.T ) {
 		
 	}
 
-}
\ No newline at end of file
+}
+
+func TestRequests_BodySizeLimit( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		maxBytes  int64
+		expected  error
+		note      string
+	} {
+		{`{"field":"testtesttest"}`, 100, nil, "Under limit"},
+		{`{"field":"testtesttest"}`, 0, nil, "No limit"},
+		{`{"field":"testtesttest"}`, 10, requests.ErrBodyTooLarge, "Over limit"},
+		{`{"field":"testtesttest"}     `, 29, nil, "Whitespace within limit"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = ValidReq{}
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(test.param) )
+		_, err := requests.ProcessBodyWithLimit( r, &testVal, &vh, test.maxBytes )
+		assert.Equal( t, test.expected, err )
+	}
+
+	// Without a content length the limit is enforced while reading
+	testutils.OutputTestNote( t, "Over limit with unknown length" )
+	var testVal = ValidReq{}
+	r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(`{"field":"testtesttest"}`) )
+	r.ContentLength = -1
+	_, err := requests.ProcessBodyWithLimit( r, &testVal, &vh, 10 )
+	assert.Equal( t, requests.ErrBodyTooLarge, err )
+	assert.Equal( t, http.StatusRequestEntityTooLarge, requests.ErrorStatus( err ))
+	assert.Equal( t, http.StatusBadRequest, requests.ErrorStatus( requests.ErrInvalidJSON ))
+
+}
+
+func TestRequests_TrailingData( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		expected  error
+		note      string
+	} {
+		{`{"field":"testtesttest"}` + "\n", nil, "Trailing newline"},
+		{`{"field":"testtesttest"}{"field":"testtesttest"}`, requests.ErrTrailingData, "Second object"},
+		{`{"field":"testtesttest"} x`, requests.ErrTrailingData, "Trailing garbage"},
+		{`{"field":"testtesttest"}}`, requests.ErrTrailingData, "Trailing brace"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = ValidReq{}
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(test.param) )
+		_, err := requests.ProcessBody( r, &testVal, &vh )
+		assert.Equal( t, test.expected, err )
+	}
+
+}
+
+func TestRequests_DecodeErrors( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		issue     string
+		note      string
+	} {
+		{``, "request body is empty", "Empty body"},
+		{"  \n ", "request body is empty", "Whitespace body"},
+		{`{"field":1}`, "field must be a string, not number", "Type mismatch"},
+		{`{"id":1}`, "id is not a recognized field", "Unknown field"},
+		{`{""}`, "syntax error at line 1, column 4 (byte 4)", "Syntax error"},
+		{"{\n  \"field\": \"test\",\n  \"field2\" \"test\"\n}", "syntax error at line 3, column 12 (byte 33)", "Syntax error on later line"},
+		{`{"field":"te`, "syntax error at line 1, column 12 (byte 12)", "Truncated body"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = ValidReq{}
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(test.param) )
+		_, err := requests.ProcessBody( r, &testVal, nil )
+		assert.True( t, errors.Is( err, requests.ErrInvalidJSON ))
+		assert.Equal( t, http.StatusBadRequest, requests.ErrorStatus( err ))
+		issuer, ok := err.(interface{ Issues( ) []string })
+		if assert.True( t, ok ) {
+			assert.Equal( t, []string{ test.issue }, issuer.Issues( ))
+		}
+	}
+
+	var syntaxErr *requests.SyntaxError
+	r, _ := http.NewRequest( "POST", "", bytes.NewBufferString("{\n\"field\" 1}") )
+	_, err := requests.ProcessBody( r, &ValidReq{}, nil )
+	if assert.True( t, errors.As( err, &syntaxErr )) {
+		assert.Equal( t, 2, syntaxErr.Line )
+		assert.Equal( t, 9, syntaxErr.Column )
+	}
+
+	var typeErr *requests.TypeMismatchError
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{"field":true}`) )
+	_, err = requests.ProcessBody( r, &ValidReq{}, nil )
+	if assert.True( t, errors.As( err, &typeErr )) {
+		assert.Equal( t, "field", typeErr.Field )
+		assert.Equal( t, "a string", typeErr.Expected )
+		assert.Equal( t, "bool", typeErr.Actual )
+	}
+
+}
+
+func TestRequests_ContentType( t *testing.T ) {
+
+	var tests = []struct{
+		contentType  string
+		isErrorNil   bool
+		note         string
+	} {
+		{"", true, "Missing content type"},
+		{"application/json", true, "JSON"},
+		{"application/json; charset=utf-8", true, "JSON with charset"},
+		{"application/merge-patch+json", true, "JSON based type"},
+		{"text/plain", false, "Plain text"},
+		{"text/csv", false, "CSV"},
+		{"not a type;;", false, "Malformed type"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(`{"field":"testtesttest"}`) )
+		if test.contentType != "" {
+			r.Header.Set( "Content-Type", test.contentType )
+		}
+		_, err := requests.ProcessBody( r, &ValidReq{}, nil )
+		if test.isErrorNil {
+			assert.Nil( t, err )
+		} else {
+			var contentErr *requests.ContentTypeError
+			assert.True( t, errors.As( err, &contentErr ))
+			assert.True( t, errors.Is( err, requests.ErrUnsupportedContentType ))
+			assert.Equal( t, http.StatusUnsupportedMediaType, requests.ErrorStatus( err ))
+		}
+	}
+
+}
+
+type FormReq struct {
+	Field    string   `json:"field" validate:"printascii,required,min=10"`
+	TaxID    uint64   `form:"taxId" json:"taxonomy"`
+	Genes    []string `json:"geneList" validate:"dive,entrezgene"`
+	Evidence *bool    `json:"evidence"`
+}
+
+func TestRequests_FormBody( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		expected  error
+		issues    int
+		note      string
+	} {
+		{"field=testtesttest&taxId=9606&geneList=7157&geneList=672&evidence=1", nil, 0, "Valid form"},
+		{"field=test", requests.ErrFailedValidation, 1, "Failed validation"},
+		{"field=testtesttest&geneList=TP53", requests.ErrFailedValidation, 1, "Failed validation in list"},
+		{"field=testtesttest&taxonomy=9606", &requests.UnknownFieldError{ Field: "taxonomy" }, 0, "Unknown field"},
+		{"field=testtesttest&taxId=human", &requests.TypeMismatchError{ Field: "taxId", Expected: "a positive integer", Actual: `"human"` }, 0, "Type mismatch"},
+		{"field=%zz", requests.ErrInvalidForm, 0, "Malformed form"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = FormReq{}
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(test.param) )
+		r.Header.Set( "Content-Type", "application/x-www-form-urlencoded" )
+		issues, err := requests.ProcessBody( r, &testVal, &vh )
+		assert.Equal( t, test.expected, err )
+		assert.Equal( t, test.issues, len(issues) )
+		if test.expected == nil {
+			assert.Equal( t, FormReq{ "testtesttest", 9606, []string{ "7157", "672" }, &[]bool{ true }[0] }, testVal )
+		}
+	}
+
+}
+
+func TestRequests_MultipartBody( t *testing.T ) {
+
+	newRequest := func( fileContent string ) *http.Request {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter( body )
+		writer.WriteField( "field", "testtesttest" )
+		file, _ := writer.CreateFormFile( "geneList", "genes.txt" )
+		file.Write( []byte(fileContent) )
+		writer.Close( )
+		r, _ := http.NewRequest( "POST", "", body )
+		r.Header.Set( "Content-Type", writer.FormDataContentType( ))
+		return r
+	}
+
+	testutils.OutputTestNote( t, "Valid upload" )
+	var testVal = FormReq{}
+	issues, err := requests.ProcessBody( newRequest( "7157\r\n672\n\n  5925  \n" ), &testVal, &vh )
+	assert.Nil( t, err )
+	assert.Equal( t, 0, len(issues) )
+	assert.Equal( t, []string{ "7157", "672", "5925" }, testVal.Genes )
+	assert.Equal( t, "testtesttest", testVal.Field )
+
+	testutils.OutputTestNote( t, "Invalid identifier in upload" )
+	issues, err = requests.ProcessBody( newRequest( "7157\nTP53" ), &FormReq{}, &vh )
+	assert.Equal( t, requests.ErrFailedValidation, err )
+	assert.Equal( t, 1, len(issues) )
+
+	testutils.OutputTestNote( t, "Upload too large" )
+	defaultMaxFileBytes := requests.DefaultMaxFileBytes
+	requests.DefaultMaxFileBytes = 8
+	_, err = requests.ProcessBody( newRequest( "7157\n672\n5925" ), &FormReq{}, &vh )
+	requests.DefaultMaxFileBytes = defaultMaxFileBytes
+	assert.Equal( t, requests.ErrFileTooLarge, err )
+	assert.Equal( t, http.StatusRequestEntityTooLarge, requests.ErrorStatus( err ))
+
+	testutils.OutputTestNote( t, "Body too large" )
+	_, err = requests.ProcessBodyWithLimit( newRequest( strings.Repeat( "7157\n", 100 )), &FormReq{}, &vh, 100 )
+	assert.Equal( t, requests.ErrBodyTooLarge, err )
+
+}
+
+func TestRequests_NDJSONBody( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		expected  error
+		issues    []string
+		items     int
+		note      string
+	} {
+		{"{\"field\":\"testtesttest\"}\n{\"field\":\"testtesttest2\"}\n", nil, []string{}, 2, "Valid lines"},
+		{"{\"field\":\"testtesttest\"}\n{\"field\":\"test\"}", requests.ErrFailedValidation, []string{ "[1] field must be greater than or equal to 10 or at least 10 in length if a string" }, 2, "Failed validation"},
+		{"", &requests.EmptyBodyError{}, []string{}, 0, "Empty body"},
+		{"{\"field\":\"testtesttest\"}\n{\"id\":1}", &requests.LineError{ Line: 2, Err: &requests.UnknownFieldError{ Field: "id" } }, []string{}, 0, "Unknown field"},
+		{"{\"field\":\"testtesttest\"}\n\n{\"field\":\"testtesttest2\"}\n\n", nil, []string{}, 2, "Blank lines"},
+		{"{\"field\":\"testtesttest\"}{\"field\":\"testtesttest2\"}", &requests.LineError{ Line: 1, Err: requests.ErrTrailingData }, []string{}, 0, "Two values on one line"},
+		{"{\"field\":\"testtesttest\"}\n{\n\"field\":\"testtesttest2\"}", &requests.SyntaxError{ Offset: 27, Line: 2, Column: 2 }, []string{}, 0, "Value over several lines"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = []ValidReq{}
+		r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(test.param) )
+		r.Header.Set( "Content-Type", "application/x-ndjson" )
+		issues, err := requests.ProcessBody( r, &testVal, &vh )
+		assert.Equal( t, test.expected, err )
+		assert.Equal( t, test.issues, issues )
+		assert.Equal( t, test.items, len(testVal) )
+	}
+
+	testutils.OutputTestNote( t, "Not a slice" )
+	r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(`{"field":"testtesttest"}`) )
+	r.Header.Set( "Content-Type", "application/x-ndjson" )
+	_, err := requests.ProcessBody( r, &ValidReq{}, &vh )
+	assert.NotNil( t, err )
+
+	testutils.OutputTestNote( t, "Lines without validation rules" )
+	var lines = []string{}
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString("\"a\"\n\"b\"\n") )
+	r.Header.Set( "Content-Type", "application/x-ndjson" )
+	issues, err := requests.ProcessBody( r, &lines, &vh )
+	assert.Nil( t, err )
+	assert.Empty( t, issues )
+	assert.Equal( t, []string{ "a", "b" }, lines )
+
+	testutils.OutputTestNote( t, "Array without validation rules" )
+	var ids = []int{}
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString("[1,2]") )
+	issues, err = requests.ProcessBody( r, &ids, &vh )
+	assert.Nil( t, err )
+	assert.Empty( t, issues )
+	assert.Equal( t, []int{ 1, 2 }, ids )
+
+	testutils.OutputTestNote( t, "Map without validation rules" )
+	var fields = map[string]int{}
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{"id":1}`) )
+	issues, err = requests.ProcessBody( r, &fields, &vh )
+	assert.Nil( t, err )
+	assert.Empty( t, issues )
+
+}
+
+func TestRequests_CompressedBody( t *testing.T ) {
+
+	compress := func( encoding, content string ) *bytes.Buffer {
+		body := &bytes.Buffer{}
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip" :
+			writer = gzip.NewWriter( body )
+		case "deflate" :
+			writer = zlib.NewWriter( body )
+		case "rawdeflate" :
+			writer, _ = flate.NewWriter( body, flate.DefaultCompression )
+		default :
+			body.WriteString( content )
+			return body
+		}
+		writer.Write( []byte(content) )
+		writer.Close( )
+		return body
+	}
+
+	var tests = []struct{
+		encoding  string
+		header    string
+		content   string
+		maxBytes  int64
+		expected  error
+		note      string
+	} {
+		{"gzip", "gzip", `{"field":"testtesttest"}`, 1000, nil, "Gzip"},
+		{"deflate", "deflate", `{"field":"testtesttest"}`, 1000, nil, "Zlib deflate"},
+		{"rawdeflate", "deflate", `{"field":"testtesttest"}`, 1000, nil, "Raw deflate"},
+		{"none", "identity", `{"field":"testtesttest"}`, 1000, nil, "Identity"},
+		{"gzip", "GZIP", `{"field":"testtesttest"}`, 1000, nil, "Upper case encoding"},
+		{"gzip", "br", `{"field":"testtesttest"}`, 1000, requests.ErrUnsupportedEncoding, "Unsupported encoding"},
+		{"none", "gzip", `{"field":"testtesttest"}`, 1000, requests.ErrInvalidEncoding, "Not actually compressed"},
+		{"gzip", "gzip", `{"field":"` + strings.Repeat( "a", 10000 ) + `"}`, 1000, requests.ErrBodyTooLarge, "Expands past limit"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		var testVal = ValidReq{}
+		r, _ := http.NewRequest( "POST", "", compress( test.encoding, test.content ))
+		r.Header.Set( "Content-Encoding", test.header )
+		_, err := requests.ProcessBodyWithLimit( r, &testVal, &vh, test.maxBytes )
+		assert.Equal( t, test.expected, err )
+		if test.expected == nil {
+			assert.Equal( t, "testtesttest", testVal.Field )
+		}
+	}
+
+	assert.Equal( t, http.StatusUnsupportedMediaType, requests.ErrorStatus( requests.ErrUnsupportedEncoding ))
+	assert.Equal( t, http.StatusBadRequest, requests.ErrorStatus( requests.ErrInvalidEncoding ))
+
+	testutils.OutputTestNote( t, "Corrupt gzip checksum" )
+	body := compress( "gzip", `{"field":"testtesttest"}` ).Bytes( )
+	body[len(body)-5] ^= 0xff
+	r, _ := http.NewRequest( "POST", "", bytes.NewBuffer( body ))
+	r.Header.Set( "Content-Encoding", "gzip" )
+	_, err := requests.ProcessBody( r, &ValidReq{}, &vh )
+	assert.Equal( t, requests.ErrInvalidEncoding, err )
+
+}
+
+type TypedInteractor struct {
+	Symbol string `json:"symbol" normalize:"upper" validate:"required"`
+	TaxID  uint64 `json:"taxId" default:"9606" validate:"taxid"`
+}
+
+type TypedReq struct {
+	Name        string            `json:"name" validate:"required,min=3"`
+	Format      string            `json:"format" default:"json" normalize:"lower" validate:"oneof=json tab2"`
+	Raw         string            `json:"raw" normalize:"-"`
+	Max         int               `json:"max" default:"10000"`
+	Throughput  []string          `json:"throughput" default:"low,high"`
+	Interactor  TypedInteractor   `json:"interactor"`
+	Others      []TypedInteractor `json:"others" validate:"dive"`
+	Meta        map[string]string `json:"meta" normalize:"lower"`
+}
+
+func TestRequests_TypedBody( t *testing.T ) {
+
+	testutils.OutputTestNote( t, "Defaults and normalization" )
+	r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(`{"name":"  test  ","format":" TAB2 ","raw":" x ","interactor":{"symbol":" tp53 "},"others":[{"symbol":"mdm2","taxId":10090}],"meta":{"a":" B "}}`) )
+	req, issues, err := requests.ProcessTypedBody[TypedReq]( r, &vh )
+	assert.Nil( t, err )
+	assert.Equal( t, 0, len(issues) )
+	assert.Equal( t, TypedReq{
+		Name: "test",
+		Format: "tab2",
+		Raw: " x ",
+		Max: 10000,
+		Throughput: []string{ "low", "high" },
+		Interactor: TypedInteractor{ "TP53", 9606 },
+		Others: []TypedInteractor{ { "MDM2", 10090 } },
+		Meta: map[string]string{ "a": "b" },
+	}, req )
+
+	testutils.OutputTestNote( t, "Explicit values replace defaults" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{"name":"test","max":0,"throughput":[]}`) )
+	req, _, err = requests.ProcessTypedBody[TypedReq]( r, nil )
+	assert.Nil( t, err )
+	assert.Equal( t, 0, req.Max )
+	assert.Equal( t, []string{}, req.Throughput )
+
+	testutils.OutputTestNote( t, "Validation after normalization" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{"name":"  ab   ","interactor":{"symbol":"   ","taxId":0}}`) )
+	req, issues, err = requests.ProcessTypedBody[TypedReq]( r, &vh )
+	assert.Equal( t, requests.ErrFailedValidation, err )
+	assert.Equal( t, 3, len(issues) )
+	assert.Equal( t, "/name", issues[0].Path )
+	assert.Equal( t, "ab", issues[0].Value )
+	assert.Equal( t, "/interactor/symbol", issues[1].Path )
+	assert.Equal( t, "/interactor/taxId", issues[2].Path )
+	assert.Equal( t, "ab", req.Name )
+
+	testutils.OutputTestNote( t, "List body" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`[{"symbol":"tp53","taxId":9606},{"symbol":"","taxId":559292}]`) )
+	list, issues, err := requests.ProcessTypedBody[[]TypedInteractor]( r, &vh )
+	assert.Equal( t, requests.ErrFailedValidation, err )
+	assert.Equal( t, 2, len(list) )
+	assert.Equal( t, "TP53", list[0].Symbol )
+	assert.Equal( t, 1, len(issues) )
+	assert.Equal( t, "/1/symbol", issues[0].Path )
+	assert.Equal( t, "[1] symbol is a required field and cannot be empty", issues[0].Message )
+
+	testutils.OutputTestNote( t, "Invalid default" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{}`) )
+	_, _, err = requests.ProcessTypedBody[struct{ Max int `default:"many"` }]( r, &vh )
+	assert.NotNil( t, err )
+
+}
+
+type PatchInteractor struct {
+	Symbol string `json:"symbol" validate:"required"`
+	TaxID  uint64 `json:"taxId" validate:"taxid"`
+}
+
+type PatchRecord struct {
+	Name        string            `json:"name" validate:"required"`
+	Aliases     []string          `json:"aliases,omitempty"`
+	Interactors []PatchInteractor `json:"interactors" validate:"dive"`
+	Score       *float64          `json:"score,omitempty"`
+	Internal    string            `json:"-"`
+	Source      string            `json:"-" form:"source"`
+}
+
+func newPatchRecord( ) PatchRecord {
+	return PatchRecord{
+		Name: "record",
+		Aliases: []string{ "a", "b" },
+		Interactors: []PatchInteractor{ { "TP53", 9606 }, { "MDM2", 9606 } },
+		Internal: "kept",
+		Source: "kept",
+	}
+}
+
+func TestRequests_MergePatch( t *testing.T ) {
+
+	score := 0.5
+	var tests = []struct{
+		param     string
+		expected  error
+		issues    int
+		result    func( *PatchRecord )
+		note      string
+	} {
+		{`{"name":"renamed"}`, nil, 0, func( p *PatchRecord ) { p.Name = "renamed" }, "Replace field"},
+		{`{"aliases":null,"score":0.5}`, nil, 0, func( p *PatchRecord ) { p.Aliases = nil; p.Score = &score }, "Remove and add fields"},
+		{`{"interactors":[{"symbol":"BRCA1","taxId":9606}]}`, nil, 0, func( p *PatchRecord ) { p.Interactors = []PatchInteractor{ { "BRCA1", 9606 } } }, "Replace list"},
+		{`{"name":null}`, requests.ErrFailedValidation, 1, nil, "Fails validation"},
+		{`{"unknown":1}`, &requests.UnknownFieldError{ Field: "unknown" }, 0, nil, "Unknown field"},
+		{`{"name":1}`, &requests.TypeMismatchError{ Field: "name", Expected: "a string", Actual: "number" }, 0, nil, "Type mismatch"},
+		{`{"name":`, &requests.SyntaxError{ Offset: 8, Line: 1, Column: 8 }, 0, nil, "Malformed patch"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		record := newPatchRecord( )
+		r, _ := http.NewRequest( "PATCH", "", bytes.NewBufferString(test.param) )
+		r.Header.Set( "Content-Type", "application/merge-patch+json" )
+		issues, err := requests.ProcessMergePatch( r, &record, &vh )
+		assert.Equal( t, test.expected, err )
+		assert.Equal( t, test.issues, len(issues) )
+
+		expected := newPatchRecord( )
+		if test.result != nil {
+			test.result( &expected )
+		}
+		assert.Equal( t, expected, record )
+	}
+
+	testutils.OutputTestNote( t, "Not a pointer to a struct" )
+	r, _ := http.NewRequest( "PATCH", "", bytes.NewBufferString(`{}`) )
+	_, err := requests.ProcessMergePatch( r, newPatchRecord( ), &vh )
+	assert.Equal( t, requests.ErrPatchTarget, err )
+
+	testutils.OutputTestNote( t, "Over limit" )
+	record := newPatchRecord( )
+	r, _ = http.NewRequest( "PATCH", "", bytes.NewBufferString(`{"name":"renamed"}`) )
+	_, err = requests.ProcessMergePatchWithLimit( r, &record, &vh, 10 )
+	assert.Equal( t, requests.ErrBodyTooLarge, err )
+	assert.Equal( t, newPatchRecord( ), record )
+
+}
+
+func TestRequests_JSONPatch( t *testing.T ) {
+
+	var tests = []struct{
+		param     string
+		expected  error
+		result    func( *PatchRecord )
+		note      string
+	} {
+		{`[{"op":"replace","path":"/name","value":"renamed"}]`, nil, func( p *PatchRecord ) { p.Name = "renamed" }, "Replace"},
+		{`[{"op":"add","path":"/aliases/1","value":"c"},{"op":"add","path":"/aliases/-","value":"d"}]`, nil, func( p *PatchRecord ) { p.Aliases = []string{ "a", "c", "b", "d" } }, "Add to list"},
+		{`[{"op":"remove","path":"/interactors/0"}]`, nil, func( p *PatchRecord ) { p.Interactors = p.Interactors[1:] }, "Remove from list"},
+		{`[{"op":"copy","from":"/interactors/0","path":"/interactors/-"},{"op":"replace","path":"/interactors/2/symbol","value":"BRCA1"}]`, nil, func( p *PatchRecord ) { p.Interactors = append( p.Interactors, PatchInteractor{ "BRCA1", 9606 } ) }, "Copy then change copy"},
+		{`[{"op":"move","from":"/aliases/0","path":"/name"}]`, nil, func( p *PatchRecord ) { p.Name = "a"; p.Aliases = []string{ "b" } }, "Move"},
+		{`[{"op":"test","path":"/interactors/1/taxId","value":9606.0},{"op":"replace","path":"/interactors/1/taxId","value":10090}]`, nil, func( p *PatchRecord ) { p.Interactors[1].TaxID = 10090 }, "Test numbers then replace"},
+		{`[{"op":"add","path":"/score","value":1},{"op":"remove","path":"/score"}]`, nil, nil, "Add then remove"},
+		{`[{"op":"replace","path":"/name","value":"x"},{"op":"test","path":"/name","value":"y"}]`, &requests.PatchError{ Index: 1, Op: "test", Path: "/name", Reason: "value does not match" }, nil, "Failed test leaves record unchanged"},
+		{`[{"op":"replace","path":"/missing","value":1}]`, &requests.PatchError{ Index: 0, Op: "replace", Path: "/missing", Reason: "path does not exist" }, nil, "Replace missing"},
+		{`[{"op":"remove","path":"/aliases/5"}]`, &requests.PatchError{ Index: 0, Op: "remove", Path: "/aliases/5", Reason: "path has an array index out of range 5" }, nil, "Index out of range"},
+		{`[{"op":"add","path":"/aliases/01","value":"x"}]`, &requests.PatchError{ Index: 0, Op: "add", Path: "/aliases/01", Reason: "path has an invalid array index 01" }, nil, "Leading zero index"},
+		{`[{"op":"add","path":"/name"}]`, &requests.PatchError{ Index: 0, Op: "add", Path: "/name", Reason: "value is required" }, nil, "Missing value"},
+		{`[{"op":"move","from":"/interactors","path":"/interactors/0"}]`, &requests.PatchError{ Index: 0, Op: "move", Path: "/interactors/0", Reason: "cannot move a value into one of its own children" }, nil, "Move into child"},
+		{`[{"op":"jump","path":"/name"}]`, &requests.PatchError{ Index: 0, Op: "jump", Path: "/name", Reason: "unknown operation" }, nil, "Unknown operation"},
+		{`[{"op":"add","path":"name","value":"x"}]`, &requests.PatchError{ Index: 0, Op: "add", Path: "name", Reason: "path must be empty or start with /" }, nil, "Invalid pointer"},
+		{`[{"op":"add","path":"/extra","value":"x"}]`, &requests.UnknownFieldError{ Field: "extra" }, nil, "Unknown field"},
+		{`[{"op":"replace","path":"/interactors/0/taxId","value":0}]`, requests.ErrFailedValidation, nil, "Fails validation"},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+		record := newPatchRecord( )
+		r, _ := http.NewRequest( "PATCH", "", bytes.NewBufferString(test.param) )
+		r.Header.Set( "Content-Type", "application/json-patch+json" )
+		_, err := requests.ProcessJSONPatch( r, &record, &vh )
+		assert.Equal( t, test.expected, err )
+
+		expected := newPatchRecord( )
+		if test.result != nil {
+			test.result( &expected )
+		}
+		assert.Equal( t, expected, record )
+	}
+
+	testutils.OutputTestNote( t, "Failed operation issues" )
+	err := &requests.PatchError{ Index: 1, Op: "test", Path: "/name", Reason: "value does not match" }
+	assert.Equal( t, []string{ "operation 1 (test /name): value does not match" }, err.Issues( ))
+	assert.True( t, errors.Is( err, requests.ErrPatchFailed ))
+	assert.Equal( t, http.StatusConflict, requests.ErrorStatus( err ))
+	assert.Equal( t, http.StatusUnprocessableEntity, requests.ErrorStatus( &requests.PatchError{ Op: "add" } ))
+
+	testutils.OutputTestNote( t, "Over limit" )
+	record := newPatchRecord( )
+	r, _ := http.NewRequest( "PATCH", "", bytes.NewBufferString(`[{"op":"remove","path":"/aliases"}]`) )
+	_, limitErr := requests.ProcessJSONPatchWithLimit( r, &record, &vh, 10 )
+	assert.Equal( t, requests.ErrBodyTooLarge, limitErr )
+	assert.Equal( t, newPatchRecord( ), record )
+
+}
+
+func TestRequests_Batch( t *testing.T ) {
+
+	testutils.OutputTestNote( t, "Mixed batch" )
+	r, _ := http.NewRequest( "POST", "", bytes.NewBufferString(`[
+		{"symbol":" tp53 ","taxId":9606},
+		{"symbol":"","taxId":9606},
+		{"symbol":"MDM2","taxId":"human"},
+		{"symbol":"BRCA1","extra":1},
+		{"symbol":"brca2"}
+	]`) )
+	items, results, err := requests.ProcessBatch[TypedInteractor]( r, &vh )
+	assert.Equal( t, requests.ErrBatchPartiallyInvalid, err )
+	assert.Equal( t, http.StatusMultiStatus, requests.ErrorStatus( err ))
+	assert.Equal( t, 5, len(items) )
+	assert.Equal( t, 5, len(results) )
+	assert.Equal( t, TypedInteractor{ "TP53", 9606 }, items[0] )
+	assert.Equal( t, requests.BatchResult{ Index: 0, Valid: true }, results[0] )
+
+	assert.False( t, results[1].Valid )
+	assert.Equal( t, "/symbol", results[1].Issues[0].Path )
+	assert.Equal( t, "required", results[1].Issues[0].Rule )
+
+	assert.False( t, results[2].Valid )
+	assert.Equal( t, TypedInteractor{}, items[2] )
+	assert.Equal( t, "type", results[2].Issues[0].Rule )
+	assert.Equal( t, "/taxId", results[2].Issues[0].Path )
+	assert.Equal( t, "taxId must be a positive integer, not string", results[2].Issues[0].Message )
+
+	assert.False( t, results[3].Valid )
+	assert.Equal( t, "unknown", results[3].Issues[0].Rule )
+	assert.Equal( t, "extra is not a recognized field", results[3].Issues[0].Message )
+
+	// Entries are created separately so each receives defaults
+	assert.True( t, results[4].Valid )
+	assert.Equal( t, TypedInteractor{ "BRCA2", 9606 }, items[4] )
+	assert.Equal( t, "2 of 5 entries were accepted.", requests.BatchSummary( results ))
+
+	testutils.OutputTestNote( t, "NDJSON batch" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString("{\"symbol\":\"TP53\"}\n{\"symbol\":\"MDM2\",\"taxId\":10090}\n") )
+	r.Header.Set( "Content-Type", "application/x-ndjson" )
+	items, results, err = requests.ProcessBatch[TypedInteractor]( r, &vh )
+	assert.Nil( t, err )
+	assert.Equal( t, []TypedInteractor{ { "TP53", 9606 }, { "MDM2", 10090 } }, items )
+	assert.Equal( t, 2, requests.CountValid( results ))
+
+	testutils.OutputTestNote( t, "NDJSON batch with two entries on a line" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString("{\"symbol\":\"TP53\"}{\"symbol\":\"MDM2\"}\n") )
+	r.Header.Set( "Content-Type", "application/x-ndjson" )
+	_, _, err = requests.ProcessBatch[TypedInteractor]( r, &vh )
+	assert.Equal( t, &requests.LineError{ Line: 1, Err: requests.ErrTrailingData }, err )
+
+	testutils.OutputTestNote( t, "Every entry invalid" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`[{"symbol":""},{"taxId":9606}]`) )
+	_, results, err = requests.ProcessBatch[TypedInteractor]( r, &vh )
+	assert.Equal( t, requests.ErrBatchInvalid, err )
+	assert.Equal( t, http.StatusUnprocessableEntity, requests.ErrorStatus( err ))
+	assert.Equal( t, 2, len(results) )
+	assert.Equal( t, 0, requests.CountValid( results ))
+
+	testutils.OutputTestNote( t, "Not an array" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`{"symbol":"TP53"}`) )
+	_, _, err = requests.ProcessBatch[TypedInteractor]( r, &vh )
+	assert.True( t, errors.Is( err, requests.ErrInvalidJSON ))
+
+	testutils.OutputTestNote( t, "Too many entries" )
+	r, _ = http.NewRequest( "POST", "", bytes.NewBufferString(`[{},{},{}]`) )
+	_, _, err = requests.ProcessBatchWithLimit[TypedInteractor]( r, &vh, 1000, 2 )
+	assert.Equal( t, requests.ErrBatchTooLarge, err )
+	assert.Equal( t, http.StatusRequestEntityTooLarge, requests.ErrorStatus( err ))
+
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package requests
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"github.com/BioGRID/biogrid-api-common/validation"
+)
+
+// Process a request body into a new value of type T limited
+// to DefaultMaxBodyBytes, see ProcessTypedBodyWithLimit
+func ProcessTypedBody[T any]( r *http.Request, v *validation.ValidationHandler ) (T, []validation.Issue, error) {
+	return ProcessTypedBodyWithLimit[T]( r, v, DefaultMaxBodyBytes )
+}
+
+// Process a request body into a new value of type T, returning
+// the value along with any validation issues. Before decoding, fields
+// are set from their default tag, such as `default:"9606"` or
+// `default:"a,b"` for a slice, so only fields missing from the body keep
+// them. Entries of lists are created by the decoder and so do not receive
+// defaults. After decoding, strings are trimmed of whitespace and changed
+// according to their normalize tag, one of `normalize:"lower"`,
+// `normalize:"upper"` or `normalize:"-"` to leave the string untouched.
+func ProcessTypedBodyWithLimit[T any]( r *http.Request, v *validation.ValidationHandler, maxBytes int64 ) (T, []validation.Issue, error) {
+	var d T
+
+	err := applyDefaults( reflect.ValueOf( &d ).Elem( ))
+	if err != nil {
+		return d, nil, err
+	}
+
+	issues, err := processBody( r, &d, v, maxBytes, func( body interface{} ) {
+		normalizeStrings( reflect.ValueOf( body ).Elem( ), "" )
+	})
+
+	return d, issues, err
+}
+
+// Set each field in a struct, and any structs nested in it,
+// from its default tag
+func applyDefaults( value reflect.Value ) (error) {
+	if value.Kind( ) != reflect.Struct {
+		return nil
+	}
+
+	t := value.Type( )
+	for i := 0; i < t.NumField( ); i++ {
+		fld := t.Field( i )
+		if fld.PkgPath != "" {
+			continue
+		}
+
+		field := value.Field( i )
+		if field.Kind( ) == reflect.Struct {
+			err := applyDefaults( field )
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		defaultVal, ok := fld.Tag.Lookup( "default" )
+		if !ok {
+			continue
+		}
+
+		values := []string{ defaultVal }
+		if field.Kind( ) == reflect.Slice {
+			values = strings.Split( defaultVal, "," )
+		}
+
+		if setFormField( field, fld.Name, values ) != nil {
+			return errors.New( "Invalid default tag for field " + t.Name( ) + "." + fld.Name )
+		}
+	}
+
+	return nil
+}
+
+// Trim every string reachable from value and apply the
+// case change in its normalize tag, where mode is the tag
+// of the field that holds value
+func normalizeStrings( value reflect.Value, mode string ) {
+	switch value.Kind( ) {
+	case reflect.String :
+		if mode == "-" || !value.CanSet( ) {
+			return
+		}
+		s := strings.TrimSpace( value.String( ))
+		switch mode {
+		case "lower" :
+			s = strings.ToLower( s )
+		case "upper" :
+			s = strings.ToUpper( s )
+		}
+		value.SetString( s )
+
+	case reflect.Ptr, reflect.Interface :
+		if !value.IsNil( ) {
+			normalizeStrings( value.Elem( ), mode )
+		}
+
+	case reflect.Slice, reflect.Array :
+		for i := 0; i < value.Len( ); i++ {
+			normalizeStrings( value.Index( i ), mode )
+		}
+
+	case reflect.Map :
+		// Map entries cannot be set in place
+		if value.Type( ).Elem( ).Kind( ) != reflect.String || mode == "-" {
+			return
+		}
+		for _, key := range value.MapKeys( ) {
+			entry := reflect.New( value.Type( ).Elem( )).Elem( )
+			entry.Set( value.MapIndex( key ))
+			normalizeStrings( entry, mode )
+			value.SetMapIndex( key, entry )
+		}
+
+	case reflect.Struct :
+		t := value.Type( )
+		for i := 0; i < t.NumField( ); i++ {
+			fld := t.Field( i )
+			if fld.PkgPath != "" {
+				continue
+			}
+			normalizeStrings( value.Field( i ), fld.Tag.Get( "normalize" ))
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output formats for access logs
+type AccessLogFormat int
+
+const (
+	JSONAccessLog AccessLogFormat = iota
+	LogfmtAccessLog
+)
+
+// A single access log line
+type AccessLogEntry struct {
+	Time		string	`json:"time"`
+	Method		string	`json:"method"`
+	Path		string	`json:"path"`
+	Route		string	`json:"route"`
+	Status		int		`json:"status"`
+	Bytes		int64	`json:"bytes"`
+	LatencyMS	float64	`json:"latency_ms"`
+	ClientIP	string	`json:"client_ip"`
+	RequestID	string	`json:"request_id,omitempty"`
+	AccessKey	string	`json:"access_key,omitempty"`
+}
+
+// AccessLogger writes one structured line for each request.
+// Requests for ExcludePaths, such as health checks, are never
+// logged. With a SampleRate between 0 and 1 only that fraction of
+// successful requests is logged, while server errors always are.
+// Forwarded client addresses are only believed from the package wide
+// TrustedProxies, which also decide the scheme of pagination links.
+// Access keys from AccessKeyParam are logged as a short hash, enough
+// to tell keys apart without recording them, unless RawAccessKeys is
+// set. Route normalizes a request path for grouping, replacing
+// numeric path segments with :id if unset.
+type AccessLogger struct {
+	Output			io.Writer
+	Format			AccessLogFormat
+	ExcludePaths	[]string
+	SampleRate		float64
+	AccessKeyParam	string
+	RawAccessKeys	bool
+	Route			func( r *http.Request ) string
+
+	mu				sync.Mutex
+}
+
+// Wrap a handler so each request it serves is logged. A request
+// whose handler panics is logged with a 500 status before the
+// panic continues on to any recovery middleware.
+func (l *AccessLogger) Handler( next http.Handler ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		start := time.Now( )
+		recorder := &statusRecorder{ ResponseWriter: w }
+		defer func( ) {
+			if recovered := recover( ); recovered != nil {
+				l.Record( r, w.Header( ), "", http.StatusInternalServerError, recorder.bytes, time.Since( start ))
+				panic( recovered )
+			}
+		}( )
+		next.ServeHTTP( recorder, r )
+		l.Record( r, w.Header( ), "", recorder.statusCode( ), recorder.bytes, time.Since( start ))
+	})
+}
+
+// Log a completed request, unless it is excluded or not sampled.
+// The request ID is taken from the response header, falling back to
+// the request header. An empty route is filled in by Route.
+func (l *AccessLogger) Record( r *http.Request, header http.Header, route string, status int, bytes int64, latency time.Duration ) {
+	if l.excluded( r.URL.Path ) || !l.sampled( status ) {
+		return
+	}
+
+	if route == "" {
+		route = l.route( r )
+	}
+
+	requestID := header.Get( RequestIDHeader )
+	if requestID == "" {
+		requestID = r.Header.Get( RequestIDHeader )
+	}
+
+	accessKeyParam := l.AccessKeyParam
+	if accessKeyParam == "" {
+		accessKeyParam = "accessKey"
+	}
+
+	l.Write( AccessLogEntry{
+		Time: time.Now( ).UTC( ).Format( time.RFC3339Nano ),
+		Method: r.Method,
+		Path: r.URL.Path,
+		Route: route,
+		Status: status,
+		Bytes: bytes,
+		LatencyMS: float64( latency.Microseconds( )) / 1000,
+		ClientIP: l.ClientIP( r ),
+		RequestID: requestID,
+		AccessKey: l.accessKey( r.URL.Query( ).Get( accessKeyParam )),
+	})
+}
+
+// The form of an access key recorded in the log
+func (l *AccessLogger) accessKey( key string ) (string) {
+	if key == "" || l.RawAccessKeys {
+		return key
+	}
+	sum := sha256.Sum256( []byte( key ))
+	return "sha256:" + hex.EncodeToString( sum[:6] )
+}
+
+// Write an entry as a single line in the logger's format
+func (l *AccessLogger) Write( entry AccessLogEntry ) {
+	var line []byte
+	if l.Format == LogfmtAccessLog {
+		line = logfmtEntry( entry )
+	} else {
+		line, _ = json.Marshal( entry )
+	}
+	line = append( line, '\n' )
+
+	output := l.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	l.mu.Lock( )
+	defer l.mu.Unlock( )
+	output.Write( line )
+}
+
+// Find the address of the client that made a request, following
+// X-Forwarded-For and X-Real-IP only through trusted proxies
+func (l *AccessLogger) ClientIP( r *http.Request ) (string) {
+	remote := remoteIP( r )
+	if !l.trusted( remote ) {
+		return remote
+	}
+
+	// Walk back through the forwarding chain until
+	// reaching an address that isn't a trusted proxy
+	if forwarded := r.Header.Get( "X-Forwarded-For" ); forwarded != "" {
+		hops := strings.Split( forwarded, "," )
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace( hops[i] )
+			if net.ParseIP( hop ) == nil {
+				break
+			}
+			remote = hop
+			if !l.trusted( hop ) {
+				return hop
+			}
+		}
+		return remote
+	}
+
+	if realIP := strings.TrimSpace( r.Header.Get( "X-Real-IP" )); net.ParseIP( realIP ) != nil {
+		return realIP
+	}
+
+	return remote
+}
+
+// Check if an address belongs to a trusted proxy
+func (l *AccessLogger) trusted( address string ) (bool) {
+	return trustedProxy( address, TrustedProxies )
+}
+
+// Find the address a request was received from
+func remoteIP( r *http.Request ) (string) {
+	remote, _, err := net.SplitHostPort( r.RemoteAddr )
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return remote
+}
+
+// Check if an address is one of a list of proxy addresses or CIDR ranges
+func trustedProxy( address string, proxies []string ) (bool) {
+	ip := net.ParseIP( address )
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range proxies {
+		if strings.Contains( proxy, "/" ) {
+			if _, network, err := net.ParseCIDR( proxy ); err == nil && network.Contains( ip ) {
+				return true
+			}
+		} else if trusted := net.ParseIP( proxy ); trusted != nil && trusted.Equal( ip ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if a path is excluded from logging
+func (l *AccessLogger) excluded( path string ) (bool) {
+	for _, excluded := range l.ExcludePaths {
+		if path == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// Decide whether to log a request with the given status
+func (l *AccessLogger) sampled( status int ) (bool) {
+	if l.SampleRate <= 0 || l.SampleRate >= 1 || status >= 500 {
+		return true
+	}
+	return rand.Float64( ) < l.SampleRate
+}
+
+// Normalize the path of a request into its route
+func (l *AccessLogger) route( r *http.Request ) (string) {
+	if l.Route != nil {
+		return l.Route( r )
+	}
+
+	segments := strings.Split( r.URL.Path, "/" )
+	for i, segment := range segments {
+		if _, err := strconv.ParseUint( segment, 10, 64 ); err == nil {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join( segments, "/" )
+}
+
+// Format an entry as logfmt key=value pairs
+func logfmtEntry( entry AccessLogEntry ) ([]byte) {
+	var buf bytes.Buffer
+	pairs := []struct{ key, value string }{
+		{ "time", entry.Time },
+		{ "method", entry.Method },
+		{ "path", entry.Path },
+		{ "route", entry.Route },
+		{ "status", strconv.Itoa( entry.Status ) },
+		{ "bytes", strconv.FormatInt( entry.Bytes, 10 ) },
+		{ "latency_ms", strconv.FormatFloat( entry.LatencyMS, 'f', -1, 64 ) },
+		{ "client_ip", entry.ClientIP },
+		{ "request_id", entry.RequestID },
+		{ "access_key", entry.AccessKey },
+	}
+
+	for _, pair := range pairs {
+		if pair.value == "" && ( pair.key == "request_id" || pair.key == "access_key" ) {
+			continue
+		}
+		if buf.Len( ) > 0 {
+			buf.WriteByte( ' ' )
+		}
+		buf.WriteString( pair.key )
+		buf.WriteByte( '=' )
+		if pair.value == "" || strings.ContainsAny( pair.value, " =\"\\" ) || strings.IndexFunc( pair.value, func( c rune ) bool { return c < 0x20 } ) >= 0 {
+			buf.WriteString( strconv.Quote( pair.value ))
+		} else {
+			buf.WriteString( pair.value )
+		}
+	}
+
+	return buf.Bytes( )
+}
+
+// A response writer that remembers the status
+// and number of bytes of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status	int
+	bytes	int64
+}
+
+func (s *statusRecorder) WriteHeader( status int ) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader( status )
+}
+
+func (s *statusRecorder) Write( data []byte ) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write( data )
+	s.bytes += int64( n )
+	return n, err
+}
+
+func (s *statusRecorder) Flush( ) {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush( )
+	}
+}
+
+// The status of the response, which is 200 if
+// the handler never wrote anything
+func (s *statusRecorder) statusCode( ) (int) {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
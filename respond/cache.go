@@ -0,0 +1,177 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes how long clients and shared caches may
+// reuse the responses of a route, and how they are validated.
+// With a Version, such as the release the data comes from, the ETag
+// is a weak tag of that version and the format of the response, and
+// requests that already hold it are answered without rendering the
+// response. Otherwise the ETag is a strong tag of a hash of the format
+// and body, or a weak one if WeakETag is set.
+type CachePolicy struct {
+	MaxAge			time.Duration
+	Private			bool
+	NoCache			bool
+	NoStore			bool
+	Immutable		bool
+	Version			string
+	WeakETag		bool
+	LastModified	time.Time
+}
+
+// Build the Cache-Control header value for the policy
+func (p CachePolicy) CacheControl( ) (string) {
+	if p.NoStore {
+		return "no-store"
+	}
+
+	directives := []string{ "public" }
+	if p.Private {
+		directives[0] = "private"
+	}
+	if p.NoCache {
+		directives = append( directives, "no-cache" )
+	}
+	directives = append( directives, "max-age=" + strconv.Itoa( int( p.MaxAge / time.Second )))
+	if p.Immutable {
+		directives = append( directives, "immutable" )
+	}
+
+	return strings.Join( directives, ", " )
+}
+
+// Build the ETag for a response body rendered by a renderer under
+// the policy, so each format of the same data has its own tag
+func (p CachePolicy) ETag( renderer Renderer, body []byte ) (string) {
+	format := renderFormat( renderer )
+	if p.Version != "" {
+		sum := sha256.Sum256( []byte( format ))
+		return `W/"` + strings.ReplaceAll( p.Version, `"`, "" ) + "-" + hex.EncodeToString( sum[:4] ) + `"`
+	}
+
+	hash := sha256.New( )
+	hash.Write( []byte( format ))
+	hash.Write( body )
+	tag := `"` + hex.EncodeToString( hash.Sum( nil )[:16] ) + `"`
+	if p.WeakETag {
+		tag = "W/" + tag
+	}
+	return tag
+}
+
+// Describe the format a renderer produces, including its settings
+// so renderers sharing a content type such as tsv and mitab differ
+func renderFormat( renderer Renderer ) (string) {
+	return fmt.Sprintf( "%s %T%+v", renderer.ContentType( ), renderer, renderer )
+}
+
+// Set the Cache-Control, ETag and Last-Modified headers of a response
+func (p CachePolicy) SetHeaders( header http.Header, etag string ) {
+	header.Set( "Cache-Control", p.CacheControl( ))
+	if etag != "" {
+		header.Set( "ETag", etag )
+	}
+	if !p.LastModified.IsZero( ) {
+		header.Set( "Last-Modified", p.LastModified.UTC( ).Format( http.TimeFormat ))
+	}
+}
+
+// Respond with data as json under a cache policy, answering
+// with 304 Not Modified if the client already holds it
+func JSONCached( w http.ResponseWriter, r *http.Request, status int, data interface{}, policy CachePolicy ) {
+	renderCached( w, r, status, JSONRenderer{}, data, policy )
+}
+
+// Render data with the renderer chosen for a request under a cache
+// policy, answering with 304 Not Modified if the client already holds
+// it. Like Render, the response and any 304 vary on Accept.
+func RenderCached( w http.ResponseWriter, r *http.Request, status int, renderer Renderer, data interface{}, policy CachePolicy ) {
+	w.Header( ).Add( "Vary", "Accept" )
+	renderCached( w, r, status, renderer, data, policy )
+}
+
+func renderCached( w http.ResponseWriter, r *http.Request, status int, renderer Renderer, data interface{}, policy CachePolicy ) {
+	if status == http.StatusOK && policy.Version != "" && NotModified( w, r, policy.ETag( renderer, nil ), policy ) {
+		return
+	}
+
+	buf := getBuffer( )
+	defer putBuffer( buf )
+
+	if err := renderer.Render( buf, data ); err != nil {
+		renderFailed( w, err, data )
+		return
+	}
+
+	etag := policy.ETag( renderer, buf.Bytes( ))
+	if status == http.StatusOK && NotModified( w, r, etag, policy ) {
+		return
+	}
+
+	policy.SetHeaders( w.Header( ), etag )
+	writeBuffer( w, status, renderer.ContentType( ), buf )
+}
+
+// Check the If-None-Match and If-Modified-Since headers of a GET
+// or HEAD request against a response's ETag and the policy, and
+// respond with 304 Not Modified if the client's copy is current
+func NotModified( w http.ResponseWriter, r *http.Request, etag string, policy CachePolicy ) (bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if !requestIsCurrent( r, etag, policy.LastModified ) {
+		return false
+	}
+
+	policy.SetHeaders( w.Header( ), etag )
+	SetAllowOrigin( w.Header( ))
+	w.WriteHeader( http.StatusNotModified )
+	return true
+}
+
+// Check if the copy a client holds matches the current one. An
+// If-None-Match header takes priority over If-Modified-Since.
+func requestIsCurrent( r *http.Request, etag string, lastModified time.Time ) (bool) {
+	if ifNoneMatch := r.Header.Get( "If-None-Match" ); ifNoneMatch != "" {
+		if etag == "" {
+			return false
+		}
+		for _, tag := range strings.Split( ifNoneMatch, "," ) {
+			tag = strings.TrimSpace( tag )
+			if tag == "*" || weakETagMatch( tag, etag ) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := r.Header.Get( "If-Modified-Since" ); ifModifiedSince != "" && !lastModified.IsZero( ) {
+		since, err := http.ParseTime( ifModifiedSince )
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate( time.Second ).After( since )
+	}
+
+	return false
+}
+
+// Compare two ETags ignoring whether they are weak, so a tag
+// weakened by Compress still matches the one it was built from
+func weakETagMatch( a string, b string ) (bool) {
+	return strings.TrimPrefix( a, "W/" ) == strings.TrimPrefix( b, "W/" )
+}
@@ -0,0 +1,307 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Smallest response body, in bytes, worth compressing
+var CompressMinBytes = 1024
+
+// Content types that are already compressed and are sent as is.
+// Entries ending in / match every subtype.
+var UncompressedContentTypes = []string{
+	"image/", "audio/", "video/",
+	"application/gzip", "application/x-gzip", "application/zip",
+	"application/x-bzip2", "application/x-xz", "application/x-7z-compressed",
+	"application/octet-stream",
+}
+
+// Encoder creates a writer that compresses everything written to it
+// into w. Writers with a Flush( ) error method are flushed whenever
+// the response is, so streamed responses reach clients incrementally.
+type Encoder func( w io.Writer ) io.WriteCloser
+
+type encoding struct {
+	name	string
+	encoder	Encoder
+}
+
+// Encodings in order of preference when a client accepts more
+// than one equally. The standard library has no brotli encoder,
+// so br is only offered once registered with RegisterEncoder.
+var encodings = []encoding{
+	{ "gzip", gzipEncoder },
+	{ "deflate", deflateEncoder },
+}
+
+// Add a content encoding such as br to those used by Compress, or
+// replace the encoder of an existing one. New encodings are preferred
+// over existing ones when a client accepts more than one equally.
+func RegisterEncoder( name string, encoder Encoder ) {
+	name = strings.ToLower( name )
+	for i := range encodings {
+		if encodings[i].name == name {
+			encodings[i].encoder = encoder
+			return
+		}
+	}
+
+	encodings = append( []encoding{{ name, encoder }}, encodings... )
+}
+
+var gzipWriters = sync.Pool{ New: func( ) interface{} {
+	return gzip.NewWriter( io.Discard )
+}}
+
+// A pooled gzip writer returned to the pool once closed
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+func (g pooledGzipWriter) Close( ) (error) {
+	err := g.Writer.Close( )
+	g.Writer.Reset( io.Discard )
+	gzipWriters.Put( g.Writer )
+	return err
+}
+
+func gzipEncoder( w io.Writer ) (io.WriteCloser) {
+	writer := gzipWriters.Get( ).(*gzip.Writer)
+	writer.Reset( w )
+	return pooledGzipWriter{ writer }
+}
+
+// Deflate in HTTP is a zlib wrapped stream rather than raw deflate
+func deflateEncoder( w io.Writer ) (io.WriteCloser) {
+	return zlib.NewWriter( w )
+}
+
+// Wrap a handler so responses are compressed with the encoding
+// a client prefers from its Accept-Encoding header. If the handler
+// panics, anything held back is dropped rather than sent, so
+// recovery middleware can still answer with an error response.
+func Compress( next http.Handler ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		compressor := NewCompressWriter( w, r )
+		defer func( ) {
+			if recovered := recover( ); recovered != nil {
+				panic( recovered )
+			}
+			compressor.Close( )
+		}( )
+		next.ServeHTTP( compressor, r )
+	})
+}
+
+// CompressWriter is a response writer that holds back the start of
+// a response until it knows whether the response is worth compressing,
+// then compresses it if so. A strong ETag on a compressed response, or
+// on a 304 Not Modified to a client accepting the encoding, is made
+// weak, since the compressed bytes differ from those it was built for.
+// Close must be called once the response is complete. Framework
+// adapters can use it to wrap their own writers.
+type CompressWriter struct {
+	w			http.ResponseWriter
+	encoding	encoding
+	status		int
+	buf			[]byte
+	decided		bool
+	encoder		io.WriteCloser
+}
+
+// Create a CompressWriter for a request, choosing
+// an encoding from its Accept-Encoding header
+func NewCompressWriter( w http.ResponseWriter, r *http.Request ) (*CompressWriter) {
+	w.Header( ).Add( "Vary", "Accept-Encoding" )
+
+	compressor := &CompressWriter{ w: w }
+	if r.Method != http.MethodHead && r.Header.Get( "Range" ) == "" {
+		compressor.encoding = negotiateEncoding( r.Header.Get( "Accept-Encoding" ))
+	}
+	return compressor
+}
+
+func (c *CompressWriter) Header( ) (http.Header) {
+	return c.w.Header( )
+}
+
+// Hold on to the status until the response is started
+func (c *CompressWriter) WriteHeader( status int ) {
+	if c.decided {
+		return
+	}
+	c.status = status
+}
+
+// Hold back writes until CompressMinBytes have been written, then
+// start the response, compressing everything written from then on
+func (c *CompressWriter) Write( p []byte ) (int, error) {
+	if !c.decided {
+		c.buf = append( c.buf, p... )
+		if len(c.buf) >= CompressMinBytes {
+			if err := c.start( true ); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	if c.encoder != nil {
+		return c.encoder.Write( p )
+	}
+	return c.w.Write( p )
+}
+
+// Start the response if it has not been, treating it as worth
+// compressing since it is being streamed, then flush everything
+// written so far through to the client
+func (c *CompressWriter) Flush( ) {
+	if !c.decided {
+		c.start( true )
+	}
+
+	if flusher, ok := c.encoder.(interface{ Flush( ) error }); ok {
+		flusher.Flush( )
+	}
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush( )
+	}
+}
+
+// Start the response without compression if nothing has been
+// written yet, such as for a response with no body
+func (c *CompressWriter) WriteHeaderNow( ) {
+	if !c.decided {
+		c.start( len(c.buf) >= CompressMinBytes )
+	}
+}
+
+// Finish the response, sending anything still held back
+func (c *CompressWriter) Close( ) (error) {
+	if !c.decided {
+		if c.status == 0 && len(c.buf) == 0 {
+			return nil
+		}
+		if err := c.start( false ); err != nil {
+			return err
+		}
+	}
+
+	if c.encoder != nil {
+		err := c.encoder.Close( )
+		c.encoder = nil
+		return err
+	}
+	return nil
+}
+
+// Write the response header, compressing the rest of the response
+// if compress is set and the response can be compressed, followed
+// by anything held back
+func (c *CompressWriter) start( compress bool ) (error) {
+	c.decided = true
+	status := c.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := c.w.Header( )
+	if header.Get( "Content-Type" ) == "" && len(c.buf) > 0 {
+		header.Set( "Content-Type", http.DetectContentType( c.buf ))
+	}
+
+	if c.encoding.encoder != nil && status == http.StatusNotModified {
+		weakenETag( header )
+	}
+
+	if compress && c.compressible( status ) {
+		weakenETag( header )
+		header.Del( "Content-Length" )
+		header.Set( "Content-Encoding", c.encoding.name )
+		c.w.WriteHeader( status )
+		c.encoder = c.encoding.encoder( c.w )
+		_, err := c.encoder.Write( c.buf )
+		c.buf = nil
+		return err
+	}
+
+	c.w.WriteHeader( status )
+	_, err := c.w.Write( c.buf )
+	c.buf = nil
+	return err
+}
+
+// Check if a response can be compressed with the chosen encoding
+func (c *CompressWriter) compressible( status int ) (bool) {
+	if c.encoding.encoder == nil || status < 200 || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+
+	header := c.w.Header( )
+	if header.Get( "Content-Encoding" ) != "" {
+		return false
+	}
+
+	contentType := strings.ToLower( header.Get( "Content-Type" ))
+	for _, uncompressed := range UncompressedContentTypes {
+		if strings.HasPrefix( contentType, uncompressed ) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Make the ETag of a response weak if it is strong
+func weakenETag( header http.Header ) {
+	if etag := header.Get( "ETag" ); etag != "" && !strings.HasPrefix( etag, "W/" ) {
+		header.Set( "ETag", "W/" + etag )
+	}
+}
+
+// Choose the registered encoding a client accepts with the
+// highest quality, returning an empty encoding if there is none
+func negotiateEncoding( header string ) (encoding) {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split( header, "," ) {
+		params := strings.Split( part, ";" )
+		name := strings.ToLower( strings.TrimSpace( params[0] ))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace( param )
+			if strings.HasPrefix( param, "q=" ) {
+				if q, err := strconv.ParseFloat( param[2:], 64 ); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := encoding{}, 0.0
+	for _, e := range encodings {
+		quality, ok := qualities[e.name]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = e, quality
+		}
+	}
+
+	return best
+}
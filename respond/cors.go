@@ -0,0 +1,250 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value of the Access-Control-Allow-Origin header added to every
+// response. Responses wrapped by CORS middleware keep the value
+// their policy decided on instead.
+var AllowOrigin = "*"
+
+// Methods and headers allowed by a CORSPolicy that lists none
+var (
+	DefaultCORSMethods = []string{ http.MethodGet, http.MethodHead, http.MethodPost }
+	DefaultCORSHeaders = []string{ "Accept", "Accept-Language", "Content-Type", "Authorization", "X-Request-ID" }
+)
+
+// CORSPolicy decides which cross origin requests browsers may make.
+// AllowedOrigins holds exact origins, * for any origin, or patterns
+// with a single wildcard such as https://*.thebiogrid.org. An
+// AllowedHeaders entry of * allows any request header.
+type CORSPolicy struct {
+	AllowedOrigins		[]string
+	AllowedMethods		[]string
+	AllowedHeaders		[]string
+	ExposedHeaders		[]string
+	AllowCredentials	bool
+	MaxAge				time.Duration
+}
+
+// Set AllowOrigin on a response if it is not empty
+func SetAllowOrigin( header http.Header ) {
+	if AllowOrigin != "" {
+		header.Set( "Access-Control-Allow-Origin", AllowOrigin )
+	}
+}
+
+// Wrap a handler so the policy is applied to every request, with
+// preflight requests answered directly with 204 No Content
+func (p *CORSPolicy) Handler( next http.Handler ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		if p.Apply( w.Header( ), r ) {
+			w.WriteHeader( http.StatusNoContent )
+			return
+		}
+		next.ServeHTTP( &corsWriter{ ResponseWriter: w, origin: w.Header( ).Get( "Access-Control-Allow-Origin" ) }, r )
+	})
+}
+
+// A response writer that restores the Access-Control-Allow-Origin
+// a policy decided on, in place of the AllowOrigin the response
+// helpers add, when the response starts
+type corsWriter struct {
+	http.ResponseWriter
+	origin		string
+	wroteHeader	bool
+}
+
+func (c *corsWriter) WriteHeader( status int ) {
+	c.restoreOrigin( )
+	c.ResponseWriter.WriteHeader( status )
+}
+
+func (c *corsWriter) Write( data []byte ) (int, error) {
+	c.restoreOrigin( )
+	return c.ResponseWriter.Write( data )
+}
+
+func (c *corsWriter) Flush( ) {
+	c.restoreOrigin( )
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush( )
+	}
+}
+
+func (c *corsWriter) restoreOrigin( ) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	RestoreAllowOrigin( c.Header( ), c.origin )
+}
+
+// Set the Access-Control-Allow-Origin of a response to the
+// value a CORS policy decided on, removing it if empty
+func RestoreAllowOrigin( header http.Header, origin string ) {
+	if origin == "" {
+		header.Del( "Access-Control-Allow-Origin" )
+	} else {
+		header.Set( "Access-Control-Allow-Origin", origin )
+	}
+}
+
+// Add the CORS headers the policy allows for a request to a
+// response header, returning true if the request is a preflight
+// request that needs no further handling
+func (p *CORSPolicy) Apply( header http.Header, r *http.Request ) (bool) {
+	preflight := r.Method == http.MethodOptions && r.Header.Get( "Access-Control-Request-Method" ) != ""
+
+	if !p.allowsAnyOrigin( ) || p.AllowCredentials {
+		header.Add( "Vary", "Origin" )
+	}
+	if preflight {
+		header.Add( "Vary", "Access-Control-Request-Method" )
+		header.Add( "Vary", "Access-Control-Request-Headers" )
+	}
+
+	origin := r.Header.Get( "Origin" )
+	if origin == "" || !p.AllowsOrigin( origin ) {
+		return preflight
+	}
+
+	if preflight {
+		method := r.Header.Get( "Access-Control-Request-Method" )
+		requested := splitHeaderList( r.Header.Get( "Access-Control-Request-Headers" ))
+		if !p.allowsMethod( method ) || !p.allowsHeaders( requested ) {
+			return true
+		}
+
+		header.Set( "Access-Control-Allow-Methods", strings.Join( p.methods( ), ", " ))
+		if len(requested) > 0 {
+			header.Set( "Access-Control-Allow-Headers", strings.Join( requested, ", " ))
+		}
+		if p.MaxAge > 0 {
+			header.Set( "Access-Control-Max-Age", strconv.Itoa( int( p.MaxAge / time.Second )))
+		}
+	} else if len(p.ExposedHeaders) > 0 {
+		ExposeHeaders( header, p.ExposedHeaders... )
+	}
+
+	if p.allowsAnyOrigin( ) && !p.AllowCredentials {
+		header.Set( "Access-Control-Allow-Origin", "*" )
+	} else {
+		header.Set( "Access-Control-Allow-Origin", origin )
+	}
+	if p.AllowCredentials {
+		header.Set( "Access-Control-Allow-Credentials", "true" )
+	}
+
+	return preflight
+}
+
+// Check if the policy allows requests from an origin
+func (p *CORSPolicy) AllowsOrigin( origin string ) (bool) {
+	origin = strings.ToLower( origin )
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower( allowed )
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		wildcard := strings.Index( allowed, "*" )
+		if wildcard < 0 {
+			continue
+		}
+		prefix, suffix := allowed[:wildcard], allowed[wildcard+1:]
+		if len(origin) > len(prefix) + len(suffix) && strings.HasPrefix( origin, prefix ) && strings.HasSuffix( origin, suffix ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the policy allows every origin
+func (p *CORSPolicy) allowsAnyOrigin( ) (bool) {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Methods allowed by the policy
+func (p *CORSPolicy) methods( ) ([]string) {
+	if len(p.AllowedMethods) == 0 {
+		return DefaultCORSMethods
+	}
+	return p.AllowedMethods
+}
+
+// Check if the policy allows a request method
+func (p *CORSPolicy) allowsMethod( method string ) (bool) {
+	for _, allowed := range p.methods( ) {
+		if strings.EqualFold( allowed, method ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the policy allows every one of a list of request headers
+func (p *CORSPolicy) allowsHeaders( requested []string ) (bool) {
+	allowed := p.AllowedHeaders
+	if len(allowed) == 0 {
+		allowed = DefaultCORSHeaders
+	}
+
+	for _, name := range requested {
+		found := false
+		for _, header := range allowed {
+			if header == "*" || strings.EqualFold( header, name ) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Add headers to the Access-Control-Expose-Headers
+// of a response, keeping any already exposed
+func ExposeHeaders( header http.Header, names ...string ) {
+	exposed := splitHeaderList( header.Get( "Access-Control-Expose-Headers" ))
+	for _, name := range names {
+		found := false
+		for _, existing := range exposed {
+			if strings.EqualFold( existing, name ) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			exposed = append( exposed, name )
+		}
+	}
+	header.Set( "Access-Control-Expose-Headers", strings.Join( exposed, ", " ))
+}
+
+// Split a comma separated header value into its trimmed entries
+func splitHeaderList( value string ) ([]string) {
+	entries := []string{}
+	for _, entry := range strings.Split( value, "," ) {
+		entry = strings.TrimSpace( entry )
+		if entry != "" {
+			entries = append( entries, entry )
+		}
+	}
+	return entries
+}
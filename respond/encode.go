@@ -0,0 +1,97 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Options used whenever json is encoded for a response
+type JSONEncoding struct {
+	Indent		string
+	EscapeHTML	bool
+}
+
+// Options for all json responses. Set Indent to pretty-print responses.
+var JSONOptions = JSONEncoding{ EscapeHTML: true }
+
+// Called whenever a response cannot be rendered, before a 500
+// error response is sent instead. The default logs the error.
+var EncodeErrorHook = func( err error, data interface{} ) {
+	log.Printf( "respond: unable to encode %T response: %v", data, err )
+}
+
+// Buffers larger than this are dropped rather than
+// pooled so one large response doesn't pin its memory
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{ New: func( ) interface{} {
+	return new( bytes.Buffer )
+}}
+
+// Get an empty buffer from the pool
+func getBuffer( ) (*bytes.Buffer) {
+	return bufferPool.Get( ).(*bytes.Buffer)
+}
+
+// Return a buffer to the pool
+func putBuffer( buf *bytes.Buffer ) {
+	if buf.Cap( ) > maxPooledBuffer {
+		return
+	}
+	buf.Reset( )
+	bufferPool.Put( buf )
+}
+
+// Create a json encoder using JSONOptions
+func newJSONEncoder( w io.Writer ) (*json.Encoder) {
+	encoder := json.NewEncoder( w )
+	encoder.SetEscapeHTML( JSONOptions.EscapeHTML )
+	if JSONOptions.Indent != "" {
+		encoder.SetIndent( "", JSONOptions.Indent )
+	}
+	return encoder
+}
+
+// Encode data with a renderer, then send it with the given status
+// and content type. Nothing is written until the data is fully
+// encoded, so data that cannot be encoded is reported through
+// EncodeErrorHook and gets a 500 error response instead.
+func WriteRendered( w http.ResponseWriter, status int, contentType string, renderer Renderer, data interface{} ) {
+	buf := getBuffer( )
+	defer putBuffer( buf )
+
+	if err := renderer.Render( buf, data ); err != nil {
+		renderFailed( w, err, data )
+		return
+	}
+
+	writeBuffer( w, status, contentType, buf )
+}
+
+// Send an encoded body with the given status and content type
+func writeBuffer( w http.ResponseWriter, status int, contentType string, buf *bytes.Buffer ) {
+	w.Header( ).Set( "Content-Type", contentType )
+	SetAllowOrigin( w.Header( ))
+	w.WriteHeader( status )
+	w.Write( buf.Bytes( ))
+}
+
+// Report a rendering failure and send a 500 error
+// response in place of the response that failed
+func renderFailed( w http.ResponseWriter, err error, data interface{} ) {
+	if EncodeErrorHook != nil {
+		EncodeErrorHook( err, data )
+	}
+
+	// An error envelope always encodes, so this cannot fail in turn
+	w.Header( ).Del( "Content-Length" )
+	JSONErrorWithDetail( w, http.StatusInternalServerError, "Unable to output results.", "" )
+}
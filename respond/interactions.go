@@ -0,0 +1,541 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotInteractions = errors.New( "Only interaction records can be output in an interaction format." )
+
+// Value used for an empty column in every interaction format
+const emptyColumn = "-"
+
+// A PSI-MI controlled vocabulary term such as MI:0018 two hybrid
+type MITerm struct {
+	ID		string
+	Name	string
+}
+
+// A cross reference to another database, with optional text
+// such as taxid:559292(Saccharomyces cerevisiae)
+type MIXref struct {
+	Database	string
+	ID			string
+	Text		string
+}
+
+// An ontology term attached to an interaction
+// for BioGRID TAB 3.0 output
+type OntologyTerm struct {
+	ID				string
+	Name			string
+	Category		string
+	QualifierIDs	[]string
+	QualifierNames	[]string
+	Type			string
+}
+
+// A single participant in an interaction
+type Interactor struct {
+	EntrezGeneID			string
+	BioGRIDID				string
+	SystematicName			string
+	OfficialSymbol			string
+	Synonyms				[]string
+	TaxID					uint64
+	OrganismName			string
+	SwissProt				[]string
+	TrEMBL					[]string
+	RefSeq					[]string
+
+	// Participant details output only in PSI-MI TAB 2.7
+	BiologicalRole			MITerm
+	ExperimentalRole		MITerm
+	Type					MITerm
+	Xrefs					[]MIXref
+	Annotations				[]string
+	Features				[]MIXref
+	Stoichiometry			string
+	IdentificationMethod	MITerm
+}
+
+// A single interaction between two interactors, holding everything
+// needed to output it as PSI-MI TAB 2.5, 2.7 or BioGRID TAB 3.0
+type InteractionRecord struct {
+	ID						uint64
+	InteractorA				Interactor
+	InteractorB				Interactor
+	ExperimentalSystem		string
+	ExperimentalSystemType	string
+	DetectionMethod			MITerm
+	InteractionType			MITerm
+	Author					string
+	PubMedID				string
+	Throughput				[]string
+	Score					string
+	Modification			string
+	Qualifications			[]string
+	Tags					[]string
+	SourceDatabase			string
+	OntologyTerms			[]OntologyTerm
+
+	// Interaction details output only in PSI-MI TAB 2.7
+	ExpansionMethod			MITerm
+	HostTaxID				uint64
+	Created					time.Time
+	Updated					time.Time
+	Negative				bool
+}
+
+// Columns of PSI-MI TAB 2.5
+var mitab25Columns = []string{
+	"ID(s) interactor A", "ID(s) interactor B",
+	"Alt. ID(s) interactor A", "Alt. ID(s) interactor B",
+	"Alias(es) interactor A", "Alias(es) interactor B",
+	"Interaction detection method(s)", "Publication 1st author(s)", "Publication Identifier(s)",
+	"Taxid interactor A", "Taxid interactor B",
+	"Interaction type(s)", "Source database(s)", "Interaction identifier(s)", "Confidence value(s)",
+}
+
+// Columns added by PSI-MI TAB 2.6 and 2.7
+var mitab27Columns = []string{
+	"Expansion method(s)",
+	"Biological role(s) interactor A", "Biological role(s) interactor B",
+	"Experimental role(s) interactor A", "Experimental role(s) interactor B",
+	"Type(s) interactor A", "Type(s) interactor B",
+	"Xref(s) interactor A", "Xref(s) interactor B", "Interaction Xref(s)",
+	"Annotation(s) interactor A", "Annotation(s) interactor B", "Interaction annotation(s)",
+	"Host organism(s)", "Interaction parameter(s)", "Creation date", "Update date",
+	"Checksum(s) interactor A", "Checksum(s) interactor B", "Interaction Checksum(s)", "Negative",
+	"Feature(s) interactor A", "Feature(s) interactor B",
+	"Stoichiometry(s) interactor A", "Stoichiometry(s) interactor B",
+	"Identification method participant A", "Identification method participant B",
+}
+
+// Columns of BioGRID TAB 3.0
+var tab3Columns = []string{
+	"BioGRID Interaction ID",
+	"Entrez Gene Interactor A", "Entrez Gene Interactor B",
+	"BioGRID ID Interactor A", "BioGRID ID Interactor B",
+	"Systematic Name Interactor A", "Systematic Name Interactor B",
+	"Official Symbol Interactor A", "Official Symbol Interactor B",
+	"Synonyms Interactor A", "Synonyms Interactor B",
+	"Experimental System", "Experimental System Type", "Author", "Publication Source",
+	"Organism ID Interactor A", "Organism ID Interactor B",
+	"Throughput", "Score", "Modification", "Qualifications", "Tags", "Source Database",
+	"SWISS-PROT Accessions Interactor A", "TREMBL Accessions Interactor A", "REFSEQ Accessions Interactor A",
+	"SWISS-PROT Accessions Interactor B", "TREMBL Accessions Interactor B", "REFSEQ Accessions Interactor B",
+	"Ontology Term IDs", "Ontology Term Names", "Ontology Term Categories",
+	"Ontology Term Qualifier IDs", "Ontology Term Qualifier Names", "Ontology Term Types",
+	"Organism Name Interactor A", "Organism Name Interactor B",
+}
+
+// Output interaction records as PSI-MI TAB 2.5,
+// with a header row unless NoHeader is set
+type MITAB25Renderer struct {
+	NoHeader	bool
+}
+
+func (MITAB25Renderer) ContentType( ) (string) {
+	return "text/tab-separated-values; charset=utf-8"
+}
+
+func (m MITAB25Renderer) Render( w io.Writer, data interface{} ) (error) {
+	return renderInteractions( w, data, mitab25Columns, !m.NoHeader, mitab25Row )
+}
+
+// Output interaction records as PSI-MI TAB 2.7,
+// with a header row unless NoHeader is set
+type MITAB27Renderer struct {
+	NoHeader	bool
+}
+
+func (MITAB27Renderer) ContentType( ) (string) {
+	return "text/tab-separated-values; charset=utf-8"
+}
+
+func (m MITAB27Renderer) Render( w io.Writer, data interface{} ) (error) {
+	columns := append( append( []string{}, mitab25Columns... ), mitab27Columns... )
+	return renderInteractions( w, data, columns, !m.NoHeader, func( record InteractionRecord ) []string {
+		return append( mitab25Row( record ), mitab27Row( record )... )
+	})
+}
+
+// Output interaction records as BioGRID TAB 3.0,
+// with a header row unless NoHeader is set
+type BioGRIDTab3Renderer struct {
+	NoHeader	bool
+}
+
+func (BioGRIDTab3Renderer) ContentType( ) (string) {
+	return "text/tab-separated-values; charset=utf-8"
+}
+
+func (b BioGRIDTab3Renderer) Render( w io.Writer, data interface{} ) (error) {
+	return renderInteractions( w, data, tab3Columns, !b.NoHeader, tab3Row )
+}
+
+// Add the interaction formats to those chosen by Negotiate, selected
+// with a format parameter of mitab25, mitab27 or tab3
+func RegisterInteractionFormats( ) {
+	RegisterFormat( "mitab25", MITAB25Renderer{} )
+	RegisterFormat( "mitab27", MITAB27Renderer{} )
+	RegisterFormat( "tab3", BioGRIDTab3Renderer{} )
+}
+
+// Write an optional header row followed by a row for each record,
+// accepting a single record or a list of records
+func renderInteractions( w io.Writer, data interface{}, columns []string, header bool, row func( InteractionRecord ) []string ) (error) {
+	records, err := interactionRecords( data )
+	if err != nil {
+		return err
+	}
+
+	if header {
+		headerRow := append( []string{}, columns... )
+		headerRow[0] = "#" + headerRow[0]
+		if err := writeTSVRow( w, headerRow ); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		if err := writeTSVRow( w, row( record )); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Collect the interaction records held in data
+func interactionRecords( data interface{} ) ([]InteractionRecord, error) {
+	switch records := data.(type) {
+	case []InteractionRecord :
+		return records, nil
+	case *[]InteractionRecord :
+		return *records, nil
+	case InteractionRecord :
+		return []InteractionRecord{ records }, nil
+	case *InteractionRecord :
+		return []InteractionRecord{ *records }, nil
+	case []*InteractionRecord :
+		list := make( []InteractionRecord, 0, len(records) )
+		for _, record := range records {
+			if record != nil {
+				list = append( list, *record )
+			}
+		}
+		return list, nil
+	}
+
+	return nil, ErrNotInteractions
+}
+
+// Build the PSI-MI TAB 2.5 columns of a record
+func mitab25Row( record InteractionRecord ) ([]string) {
+	var confidence []string
+	if record.Score != "" {
+		confidence = []string{ formatXref( MIXref{ Database: "score", ID: record.Score } ) }
+	}
+
+	var publication []string
+	if record.PubMedID != "" {
+		publication = []string{ formatXref( MIXref{ Database: "pubmed", ID: record.PubMedID } ) }
+	}
+
+	sourceDatabase := MITerm{ ID: "MI:0463", Name: "biogrid" }
+	if record.SourceDatabase != "" && !strings.EqualFold( record.SourceDatabase, "biogrid" ) {
+		sourceDatabase = MITerm{ Name: strings.ToLower( record.SourceDatabase ) }
+	}
+
+	var interactionIDs []string
+	if record.ID > 0 {
+		interactionIDs = []string{ formatXref( MIXref{ Database: "biogrid", ID: strconv.FormatUint( record.ID, 10 ) } ) }
+	}
+
+	return []string{
+		mitabColumn( mitabIdentifier( record.InteractorA )),
+		mitabColumn( mitabIdentifier( record.InteractorB )),
+		mitabColumn( mitabAlternativeIDs( record.InteractorA )),
+		mitabColumn( mitabAlternativeIDs( record.InteractorB )),
+		mitabColumn( mitabAliases( record.InteractorA )),
+		mitabColumn( mitabAliases( record.InteractorB )),
+		mitabColumn( formatTerms( record.DetectionMethod )),
+		mitabColumn( quoteNonEmpty( record.Author )),
+		mitabColumn( publication ),
+		mitabColumn( mitabTaxID( record.InteractorA.TaxID, record.InteractorA.OrganismName )),
+		mitabColumn( mitabTaxID( record.InteractorB.TaxID, record.InteractorB.OrganismName )),
+		mitabColumn( formatTerms( record.InteractionType )),
+		mitabColumn( formatTerms( sourceDatabase )),
+		mitabColumn( interactionIDs ),
+		mitabColumn( confidence ),
+	}
+}
+
+// Build the columns PSI-MI TAB 2.7 adds to a record
+func mitab27Row( record InteractionRecord ) ([]string) {
+	var annotations []string
+	for _, qualification := range record.Qualifications {
+		annotations = append( annotations, formatXref( MIXref{ Database: "comment", ID: qualification } ))
+	}
+
+	return []string{
+		mitabColumn( formatTerms( record.ExpansionMethod )),
+		mitabColumn( formatTerms( record.InteractorA.BiologicalRole )),
+		mitabColumn( formatTerms( record.InteractorB.BiologicalRole )),
+		mitabColumn( formatTerms( record.InteractorA.ExperimentalRole )),
+		mitabColumn( formatTerms( record.InteractorB.ExperimentalRole )),
+		mitabColumn( formatTerms( record.InteractorA.Type )),
+		mitabColumn( formatTerms( record.InteractorB.Type )),
+		mitabColumn( formatXrefs( record.InteractorA.Xrefs )),
+		mitabColumn( formatXrefs( record.InteractorB.Xrefs )),
+		emptyColumn,
+		mitabColumn( mitabAnnotations( record.InteractorA.Annotations )),
+		mitabColumn( mitabAnnotations( record.InteractorB.Annotations )),
+		mitabColumn( annotations ),
+		mitabColumn( mitabTaxID( record.HostTaxID, "" )),
+		emptyColumn,
+		mitabColumn( formatDates( record.Created )),
+		mitabColumn( formatDates( record.Updated )),
+		emptyColumn,
+		emptyColumn,
+		emptyColumn,
+		strconv.FormatBool( record.Negative ),
+		mitabColumn( formatXrefs( record.InteractorA.Features )),
+		mitabColumn( formatXrefs( record.InteractorB.Features )),
+		mitabColumn( quoteNonEmpty( record.InteractorA.Stoichiometry )),
+		mitabColumn( quoteNonEmpty( record.InteractorB.Stoichiometry )),
+		mitabColumn( formatTerms( record.InteractorA.IdentificationMethod )),
+		mitabColumn( formatTerms( record.InteractorB.IdentificationMethod )),
+	}
+}
+
+// Build the BioGRID TAB 3.0 columns of a record
+func tab3Row( record InteractionRecord ) ([]string) {
+	a, b := record.InteractorA, record.InteractorB
+
+	publication := ""
+	if record.PubMedID != "" {
+		publication = "PUBMED:" + record.PubMedID
+	}
+
+	var ontologyIDs, ontologyNames, ontologyCategories, qualifierIDs, qualifierNames, ontologyTypes []string
+	for _, term := range record.OntologyTerms {
+		ontologyIDs = append( ontologyIDs, term.ID )
+		ontologyNames = append( ontologyNames, term.Name )
+		ontologyCategories = append( ontologyCategories, term.Category )
+		qualifierIDs = append( qualifierIDs, tab3Qualifiers( term.QualifierIDs ))
+		qualifierNames = append( qualifierNames, tab3Qualifiers( term.QualifierNames ))
+		ontologyTypes = append( ontologyTypes, term.Type )
+	}
+
+	return []string{
+		tab3Value( formatID( record.ID )),
+		tab3Value( a.EntrezGeneID ), tab3Value( b.EntrezGeneID ),
+		tab3Value( a.BioGRIDID ), tab3Value( b.BioGRIDID ),
+		tab3Value( a.SystematicName ), tab3Value( b.SystematicName ),
+		tab3Value( a.OfficialSymbol ), tab3Value( b.OfficialSymbol ),
+		tab3Column( a.Synonyms ), tab3Column( b.Synonyms ),
+		tab3Value( record.ExperimentalSystem ),
+		tab3Value( record.ExperimentalSystemType ),
+		tab3Value( record.Author ),
+		tab3Value( publication ),
+		tab3Value( formatID( a.TaxID )), tab3Value( formatID( b.TaxID )),
+		tab3Column( record.Throughput ),
+		tab3Value( record.Score ),
+		tab3Value( record.Modification ),
+		tab3Column( record.Qualifications ),
+		tab3Column( record.Tags ),
+		tab3Value( record.SourceDatabase ),
+		tab3Column( a.SwissProt ), tab3Column( a.TrEMBL ), tab3Column( a.RefSeq ),
+		tab3Column( b.SwissProt ), tab3Column( b.TrEMBL ), tab3Column( b.RefSeq ),
+		tab3Column( ontologyIDs ),
+		tab3Column( ontologyNames ),
+		tab3Column( ontologyCategories ),
+		tab3Column( qualifierIDs ),
+		tab3Column( qualifierNames ),
+		tab3Column( ontologyTypes ),
+		tab3Value( a.OrganismName ), tab3Value( b.OrganismName ),
+	}
+}
+
+// Identify an interactor by its Entrez Gene ID, falling back
+// to its BioGRID ID for interactors without one
+func mitabIdentifier( i Interactor ) ([]string) {
+	if i.EntrezGeneID != "" {
+		return []string{ formatXref( MIXref{ Database: "entrez gene/locuslink", ID: i.EntrezGeneID } ) }
+	}
+	if i.BioGRIDID != "" {
+		return []string{ formatXref( MIXref{ Database: "biogrid", ID: i.BioGRIDID } ) }
+	}
+	return nil
+}
+
+// List the other identifiers of an interactor
+func mitabAlternativeIDs( i Interactor ) ([]string) {
+	xrefs := []MIXref{}
+	if i.BioGRIDID != "" && i.EntrezGeneID != "" {
+		xrefs = append( xrefs, MIXref{ Database: "biogrid", ID: i.BioGRIDID } )
+	}
+	if i.OfficialSymbol != "" {
+		xrefs = append( xrefs, MIXref{ Database: "entrez gene/locuslink", ID: i.OfficialSymbol } )
+	}
+	for _, accession := range i.SwissProt {
+		xrefs = append( xrefs, MIXref{ Database: "uniprot/swiss-prot", ID: accession } )
+	}
+	for _, accession := range i.TrEMBL {
+		xrefs = append( xrefs, MIXref{ Database: "uniprot/trembl", ID: accession } )
+	}
+	for _, accession := range i.RefSeq {
+		xrefs = append( xrefs, MIXref{ Database: "refseq", ID: accession } )
+	}
+	return formatXrefs( xrefs )
+}
+
+// List the systematic name and synonyms of an interactor
+func mitabAliases( i Interactor ) ([]string) {
+	xrefs := []MIXref{}
+	if i.SystematicName != "" {
+		xrefs = append( xrefs, MIXref{ Database: "entrez gene/locuslink", ID: i.SystematicName, Text: "gene name synonym" } )
+	}
+	for _, synonym := range i.Synonyms {
+		xrefs = append( xrefs, MIXref{ Database: "entrez gene/locuslink", ID: synonym, Text: "gene name synonym" } )
+	}
+	return formatXrefs( xrefs )
+}
+
+// Format free text annotations as comments
+func mitabAnnotations( annotations []string ) ([]string) {
+	values := []string{}
+	for _, annotation := range annotations {
+		values = append( values, formatXref( MIXref{ Database: "comment", ID: annotation } ))
+	}
+	return values
+}
+
+// Format a taxonomy ID with its organism name
+func mitabTaxID( taxID uint64, organism string ) ([]string) {
+	if taxID == 0 {
+		return nil
+	}
+	return []string{ formatXref( MIXref{ Database: "taxid", ID: formatID( taxID ), Text: organism } ) }
+}
+
+// Format a numeric identifier, leaving it empty when unset
+func formatID( taxID uint64 ) (string) {
+	if taxID == 0 {
+		return ""
+	}
+	return strconv.FormatUint( taxID, 10 )
+}
+
+// Format a date as PSI-MI TAB expects, leaving it empty when unset
+func formatDates( date time.Time ) ([]string) {
+	if date.IsZero( ) {
+		return nil
+	}
+	return []string{ date.Format( "2006/01/02" ) }
+}
+
+// Format a PSI-MI term such as psi-mi:"MI:0018"(two hybrid),
+// leaving out terms that are not set
+func formatTerms( terms ...MITerm ) ([]string) {
+	values := []string{}
+	for _, term := range terms {
+		if term.ID == "" && term.Name == "" {
+			continue
+		}
+		values = append( values, formatXref( MIXref{ Database: "psi-mi", ID: term.ID, Text: term.Name } ))
+	}
+	return values
+}
+
+// Format a list of cross references
+func formatXrefs( xrefs []MIXref ) ([]string) {
+	values := make( []string, 0, len(xrefs) )
+	for _, xref := range xrefs {
+		values = append( values, formatXref( xref ))
+	}
+	return values
+}
+
+// Format a cross reference as database:identifier(text)
+func formatXref( xref MIXref ) (string) {
+	value := quoteMITAB( xref.Database ) + ":" + quoteMITAB( xref.ID )
+	if xref.ID == "" {
+		value = quoteMITAB( xref.Database ) + ":" + emptyColumn
+	}
+	if xref.Text != "" {
+		value += "(" + quoteMITAB( xref.Text ) + ")"
+	}
+	return value
+}
+
+// Quote a free text value, leaving out empty ones
+func quoteNonEmpty( value string ) ([]string) {
+	if value == "" {
+		return nil
+	}
+	return []string{ quoteMITAB( value ) }
+}
+
+var mitabEscaper = strings.NewReplacer( `\`, `\\`, `"`, `\"` )
+
+// Surround a value in double quotes if it contains any of the
+// characters PSI-MI TAB uses as separators, escaping quotes within it
+func quoteMITAB( value string ) (string) {
+	value = tsvReplacer.Replace( value )
+	if !strings.ContainsAny( value, `|():"` ) {
+		return value
+	}
+	return `"` + mitabEscaper.Replace( value ) + `"`
+}
+
+// Join the values of a PSI-MI TAB column with |,
+// using - for an empty column
+func mitabColumn( values []string ) (string) {
+	if len(values) == 0 {
+		return emptyColumn
+	}
+	return strings.Join( values, "|" )
+}
+
+// Join the values of a BioGRID TAB 3.0 column with |,
+// using - for an empty column
+func tab3Column( values []string ) (string) {
+	nonEmpty := []string{}
+	for _, value := range values {
+		if value != "" {
+			nonEmpty = append( nonEmpty, strings.ReplaceAll( value, "|", " " ))
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return emptyColumn
+	}
+	return strings.Join( nonEmpty, "|" )
+}
+
+// Use - for an empty BioGRID TAB 3.0 value
+func tab3Value( value string ) (string) {
+	if value == "" {
+		return emptyColumn
+	}
+	return value
+}
+
+// Join the qualifiers of a single ontology term with ^,
+// using - for a term without qualifiers
+func tab3Qualifiers( qualifiers []string ) (string) {
+	if len(qualifiers) == 0 {
+		return emptyColumn
+	}
+	return strings.Join( qualifiers, "^" )
+}
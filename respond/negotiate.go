@@ -0,0 +1,199 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Name of the query parameter that selects an output
+// format by name, taking priority over the Accept header
+var FormatParam = "format"
+
+// An output format, selected by name through FormatParam
+// or by any of its media types through the Accept header
+type format struct {
+	name		string
+	mediaTypes	[]string
+	renderer	Renderer
+}
+
+// Formats in order of preference, the first
+// is used when any format is acceptable
+var formats = []format{
+	{ "json", []string{ "application/json" }, JSONRenderer{} },
+	{ "tsv", []string{ "text/tab-separated-values", "text/tsv" }, TSVRenderer{} },
+	{ "csv", []string{ "text/csv" }, CSVRenderer{} },
+	{ "xml", []string{ "application/xml", "text/xml" }, XMLRenderer{} },
+}
+
+// Add an output format to those chosen by Negotiate, or
+// replace the renderer of an existing format with the same name.
+// New formats are the least preferred when any format is acceptable.
+func RegisterFormat( name string, renderer Renderer, mediaTypes ...string ) {
+	name = strings.ToLower( name )
+	for i := range formats {
+		if formats[i].name == name {
+			formats[i].renderer = renderer
+			if len(mediaTypes) > 0 {
+				formats[i].mediaTypes = mediaTypes
+			}
+			return
+		}
+	}
+
+	formats = append( formats, format{ name, mediaTypes, renderer } )
+}
+
+// Choose a renderer for a request from FormatParam or the
+// Accept header. A request with neither gets json, and so does
+// one where json is acceptable and either a wildcard ranks as high
+// as any other format or text/html is accepted, as browsers send.
+// Returns false if none of the registered formats are acceptable.
+func NegotiateRenderer( r *http.Request ) (Renderer, bool) {
+	if name := r.URL.Query( ).Get( FormatParam ); name != "" {
+		name = strings.ToLower( name )
+		for _, f := range formats {
+			if f.name == name {
+				return f.renderer, true
+			}
+		}
+		return nil, false
+	}
+
+	accept := r.Header.Get( "Accept" )
+	if strings.TrimSpace( accept ) == "" {
+		return formats[0].renderer, true
+	}
+
+	ranges := parseAccept( accept )
+	for _, mediaRange := range ranges {
+		if mediaRange.name == "text/html" && formats[0].accepts( ranges ) {
+			return formats[0].renderer, true
+		}
+	}
+
+	// Work through the ranges from the most preferred, taking
+	// those of equal quality together so json wins any tie
+	for start := 0; start < len(ranges); {
+		end := start
+		for end < len(ranges) && ranges[end].quality == ranges[start].quality {
+			end++
+		}
+
+		tied := ranges[start:end]
+		if formats[0].accepts( tied ) {
+			return formats[0].renderer, true
+		}
+		for _, mediaRange := range tied {
+			for _, f := range formats {
+				if f.accepts( []acceptRange{ mediaRange } ) {
+					return f.renderer, true
+				}
+			}
+		}
+
+		start = end
+	}
+
+	return nil, false
+}
+
+// Check if any of a list of media ranges accepts a format
+func (f format) accepts( ranges []acceptRange ) (bool) {
+	for _, mediaRange := range ranges {
+		for _, mediaType := range f.mediaTypes {
+			if matchMediaRange( mediaRange.name, mediaType ) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Render data in the format chosen by NegotiateRenderer, responding
+// with 406 Not Acceptable if none of the formats can be used
+func Negotiate( w http.ResponseWriter, r *http.Request, status int, data interface{} ) {
+	renderer, ok := NegotiateRenderer( r )
+	if !ok {
+		w.Header( ).Add( "Vary", "Accept" )
+		JSONErrorWithDetail( w, http.StatusNotAcceptable, "Requested format is not available.", "Available formats are " + strings.Join( formatNames( ), ", " ) + "." )
+		return
+	}
+
+	Render( w, status, renderer, data )
+}
+
+// Render data with the given renderer and send it with its
+// content type. Data that cannot be rendered in that format
+// gets a 500 error response instead.
+func Render( w http.ResponseWriter, status int, renderer Renderer, data interface{} ) {
+	w.Header( ).Add( "Vary", "Accept" )
+	WriteRendered( w, status, renderer.ContentType( ), renderer, data )
+}
+
+// Names of every registered format
+func formatNames( ) ([]string) {
+	names := make( []string, len(formats) )
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// A media range from an Accept header and its quality
+type acceptRange struct {
+	name	string
+	quality	float64
+}
+
+// Parse an Accept header into its media ranges, most preferred
+// first, leaving out any with a quality of zero
+func parseAccept( header string ) ([]acceptRange) {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split( header, "," ) {
+		name, params, err := mime.ParseMediaType( strings.TrimSpace( part ))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat( q, 64 )
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append( ranges, acceptRange{ name, quality } )
+	}
+
+	sort.SliceStable( ranges, func( i, j int ) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	return ranges
+}
+
+// Check if a media type falls within a media range
+// such as */*, text/* or text/csv
+func matchMediaRange( mediaRange string, mediaType string ) (bool) {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	if strings.HasSuffix( mediaRange, "/*" ) {
+		return strings.HasPrefix( mediaType, strings.TrimSuffix( mediaRange, "*" ))
+	}
+
+	return false
+}
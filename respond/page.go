@@ -0,0 +1,159 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Names of the query parameters holding the
+// offset of the first result and the page size
+var (
+	StartParam = "start"
+	MaxParam = "max"
+)
+
+// Proxies, as addresses or CIDR ranges, whose forwarding headers
+// are believed, both for the scheme of pagination links and for
+// the client addresses recorded by an AccessLogger
+var TrustedProxies = []string{}
+
+// A single page of results out of Total results
+// matching a request, starting at offset Start
+type Page struct {
+	Data	interface{}
+	Total	uint64
+	Start	uint64
+	Max		uint64
+}
+
+type JSONPaginatedResponse struct {
+	Data		interface{}	`json:"data"`
+	Total		uint64		`json:"total"`
+	Start		uint64		`json:"start"`
+	Max			uint64		`json:"max"`
+	Next		string		`json:"next,omitempty"`
+	Prev		string		`json:"prev,omitempty"`
+}
+
+// Links to the pages around a page, empty when there is no such page
+type PageLinks struct {
+	First	string
+	Prev	string
+	Next	string
+	Last	string
+}
+
+// Respond with a page of results wrapped in a paginated envelope,
+// along with Link and X-Total-Count headers describing the pages
+func JSONPage( w http.ResponseWriter, r *http.Request, status int, page Page ) {
+	links := PaginationLinks( r, page )
+	SetPaginationHeaders( w.Header( ), page, links )
+	JSONCode( w, status, PaginatedResponse( page, links ))
+}
+
+// Build the paginated envelope for a page
+func PaginatedResponse( page Page, links PageLinks ) (JSONPaginatedResponse) {
+	return JSONPaginatedResponse{
+		Data: page.Data,
+		Total: page.Total,
+		Start: page.Start,
+		Max: page.Max,
+		Next: links.Next,
+		Prev: links.Prev,
+	}
+}
+
+// Set the X-Total-Count header and an RFC 8288 Link header
+// with a first, prev, next and last link where they exist
+func SetPaginationHeaders( header http.Header, page Page, links PageLinks ) {
+	header.Set( "X-Total-Count", strconv.FormatUint( page.Total, 10 ))
+
+	relations := []string{}
+	for _, link := range []struct{ rel, url string }{
+		{ "first", links.First },
+		{ "prev", links.Prev },
+		{ "next", links.Next },
+		{ "last", links.Last },
+	} {
+		if link.url != "" {
+			relations = append( relations, "<" + link.url + ">; rel=\"" + link.rel + "\"" )
+		}
+	}
+
+	if len(relations) > 0 {
+		header.Set( "Link", strings.Join( relations, ", " ))
+	}
+
+	// Browsers hide both headers from scripts unless exposed
+	ExposeHeaders( header, "Link", "X-Total-Count" )
+}
+
+// Build links to the pages around a page by replacing the
+// StartParam and MaxParam of the current request url. A page
+// with a Max of zero holds every result and has no links.
+func PaginationLinks( r *http.Request, page Page ) (PageLinks) {
+	links := PageLinks{}
+	if page.Max == 0 {
+		return links
+	}
+
+	links.First = pageURL( r, 0, page.Max )
+
+	if page.Start > 0 {
+		prev := uint64(0)
+		if page.Start > page.Max {
+			prev = page.Start - page.Max
+		}
+		links.Prev = pageURL( r, prev, page.Max )
+	}
+
+	if page.Start + page.Max < page.Total {
+		links.Next = pageURL( r, page.Start + page.Max, page.Max )
+	}
+
+	last := uint64(0)
+	if page.Total > 0 {
+		last = (( page.Total - 1 ) / page.Max ) * page.Max
+	}
+	links.Last = pageURL( r, last, page.Max )
+
+	return links
+}
+
+// Build an absolute url for the current request with
+// a different start and max
+func pageURL( r *http.Request, start uint64, max uint64 ) (string) {
+	link := url.URL{
+		Scheme: requestScheme( r ),
+		Host: r.Host,
+		Path: r.URL.Path,
+	}
+
+	query := r.URL.Query( )
+	query.Set( StartParam, strconv.FormatUint( start, 10 ))
+	query.Set( MaxParam, strconv.FormatUint( max, 10 ))
+	link.RawQuery = query.Encode( )
+
+	return link.String( )
+}
+
+// Find the scheme a client used to make a request, including
+// through a trusted proxy that terminates tls
+func requestScheme( r *http.Request ) (string) {
+	if proto := r.Header.Get( "X-Forwarded-Proto" ); proto != "" && trustedProxy( remoteIP( r ), TrustedProxies ) {
+		proto = strings.ToLower( strings.TrimSpace( strings.Split( proto, "," )[0] ))
+		if proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorFormat selects how the error helpers
+// in this package format their responses
+type ErrorFormat int
+
+const (
+	// The message, status, detail and issues envelope
+	LegacyErrorFormat ErrorFormat = iota
+
+	// RFC 7807 problem details served as application/problem+json
+	ProblemErrorFormat
+)
+
+// Format used by every error helper in this package. Services
+// opt in to problem details by setting this once at startup.
+var ErrorResponseFormat = LegacyErrorFormat
+
+// ProblemDetails is an RFC 7807 problem details object. Extensions
+// are output as additional members alongside the standard ones.
+type ProblemDetails struct {
+	Type		string
+	Title		string
+	Status		int
+	Detail		string
+	Instance	string
+	Extensions	map[string]interface{}
+}
+
+// Output the standard members, leaving out empty ones,
+// followed by any extension members
+func (p ProblemDetails) MarshalJSON( ) ([]byte, error) {
+	members := map[string]interface{}{}
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+
+	members["type"] = p.Type
+	if p.Type == "" {
+		members["type"] = "about:blank"
+	}
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal( members )
+}
+
+// Send a problem details response with its own status code
+func Problem( w http.ResponseWriter, problem ProblemDetails ) {
+	writeJSON( w, problem.Status, "application/problem+json", problem )
+}
+
+// Send an error envelope in the format chosen by
+// ErrorResponseFormat, converting it to problem details
+// when needed. The message becomes the title, and issues
+// and data become extension members. The ID of the request
+// is included when it has one.
+func errorCode( w http.ResponseWriter, status int, resp interface{} ) {
+	requestID := responseRequestID( w )
+	if ErrorResponseFormat != ProblemErrorFormat {
+		switch envelope := resp.(type) {
+		case JSONErrorResponse :
+			envelope.RequestID = requestID
+			resp = envelope
+		case JSONErrorDataResponse :
+			envelope.RequestID = requestID
+			resp = envelope
+		}
+		JSONCode( w, status, resp )
+		return
+	}
+
+	problem := ProblemDetails{ Status: status, Extensions: map[string]interface{}{} }
+	if requestID != "" {
+		problem.Extensions["requestId"] = requestID
+	}
+	switch envelope := resp.(type) {
+	case JSONErrorResponse :
+		problem.Title = envelope.Message
+		problem.Detail = envelope.Detail
+		if len(envelope.Issues) > 0 {
+			problem.Extensions["issues"] = envelope.Issues
+		}
+	case JSONErrorDataResponse :
+		problem.Title = envelope.Message
+		problem.Detail = envelope.Detail
+		if envelope.Data != nil {
+			problem.Extensions["data"] = envelope.Data
+		}
+	default :
+		problem.Title = http.StatusText( status )
+	}
+
+	Problem( w, problem )
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"github.com/BioGRID/biogrid-api-common/envhandler"
+)
+
+// Environment variable that, when true, includes the panic value
+// and stack trace in the error response for a recovered panic
+var DebugEnvVar = "API_DEBUG"
+
+// Logger for recovered panics and their stack traces
+var PanicLogger = log.New( os.Stderr, "", log.LstdFlags )
+
+// Wrap a handler so a panic while serving a request is logged
+// and answered with a 500 error response instead of dropping
+// the connection
+func RecoverHandler( next http.Handler ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		recorder := &statusRecorder{ ResponseWriter: w }
+		defer func( ) {
+			if recovered := recover( ); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic( recovered )
+				}
+				HandlePanic( w, r, recovered, debug.Stack( ), recorder.status != 0 )
+			}
+		}( )
+		next.ServeHTTP( recorder, r )
+	})
+}
+
+// Log a recovered panic with its stack trace, then send a 500 error
+// response unless the handler had already started its response. The
+// panic is only described in the response when DebugEnvVar is true.
+func HandlePanic( w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte, written bool ) {
+	requestID := responseRequestID( w )
+	if requestID == "" {
+		requestID = RequestID( r.Context( ))
+	}
+	PanicLogger.Printf( "panic serving %s %s [%s]: %v\n%s", r.Method, r.URL.Path, requestID, recovered, stack )
+
+	if written {
+		return
+	}
+
+	detail := ""
+	if envhandler.GetEnvAsBool( DebugEnvVar, false ) {
+		detail = fmt.Sprintf( "%v\n%s", recovered, stack )
+	}
+
+	// Drop headers describing the body the handler meant to send
+	w.Header( ).Del( "Content-Length" )
+	w.Header( ).Del( "Content-Encoding" )
+	JSONErrorWithDetail( w, http.StatusInternalServerError, "An unexpected error occurred while processing the request.", detail )
+}
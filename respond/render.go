@@ -0,0 +1,232 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var ErrNotTabular = errors.New( "Only structs and lists of structs can be output as tabular data." )
+
+// Renderer writes data in a single output format
+type Renderer interface {
+	ContentType( ) string
+	Render( w io.Writer, data interface{} ) error
+}
+
+// Output data as json
+type JSONRenderer struct{}
+
+func (JSONRenderer) ContentType( ) (string) {
+	return "application/json; charset=utf-8"
+}
+
+func (JSONRenderer) Render( w io.Writer, data interface{} ) (error) {
+	return newJSONEncoder( w ).Encode( data )
+}
+
+// Output data as xml. Lists are wrapped in a single
+// root element named by Root, or results if empty.
+type XMLRenderer struct {
+	Root	string
+}
+
+func (XMLRenderer) ContentType( ) (string) {
+	return "application/xml; charset=utf-8"
+}
+
+func (x XMLRenderer) Render( w io.Writer, data interface{} ) (error) {
+	if _, err := io.WriteString( w, xml.Header ); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder( w )
+	value := reflect.Indirect( reflect.ValueOf( data ))
+	if value.Kind( ) == reflect.Slice || value.Kind( ) == reflect.Array {
+		root := x.Root
+		if root == "" {
+			root = "results"
+		}
+		start := xml.StartElement{ Name: xml.Name{ Local: root }}
+		if err := encoder.EncodeToken( start ); err != nil {
+			return err
+		}
+		for i := 0; i < value.Len( ); i++ {
+			if err := encoder.Encode( value.Index( i ).Interface( )); err != nil {
+				return err
+			}
+		}
+		if err := encoder.EncodeToken( start.End( )); err != nil {
+			return err
+		}
+		return encoder.Flush( )
+	}
+
+	return encoder.Encode( data )
+}
+
+// Output a struct or list of structs as comma separated
+// values with a header row
+type CSVRenderer struct{}
+
+func (CSVRenderer) ContentType( ) (string) {
+	return "text/csv; charset=utf-8"
+}
+
+func (CSVRenderer) Render( w io.Writer, data interface{} ) (error) {
+	header, rows, err := TabularRows( data )
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter( w )
+	writer.Write( header )
+	writer.WriteAll( rows )
+	return writer.Error( )
+}
+
+// Output a struct or list of structs as tab separated values
+// with a header row. Tabs and line breaks within values are
+// replaced by spaces.
+type TSVRenderer struct{}
+
+func (TSVRenderer) ContentType( ) (string) {
+	return "text/tab-separated-values; charset=utf-8"
+}
+
+func (TSVRenderer) Render( w io.Writer, data interface{} ) (error) {
+	header, rows, err := TabularRows( data )
+	if err != nil {
+		return err
+	}
+
+	if err := writeTSVRow( w, header ); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writeTSVRow( w, row ); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var tsvReplacer = strings.NewReplacer( "\t", " ", "\r\n", " ", "\n", " ", "\r", " " )
+
+// Write a single tab separated row
+func writeTSVRow( w io.Writer, row []string ) (error) {
+	fields := make( []string, len(row) )
+	for i, field := range row {
+		fields[i] = tsvReplacer.Replace( field )
+	}
+	_, err := io.WriteString( w, strings.Join( fields, "\t" ) + "\n" )
+	return err
+}
+
+// Convert a struct or list of structs into a header row and
+// a row of values for each struct. Column names come from the
+// csv tag, then the json tag, then the field name, and fields
+// tagged with - are left out. Lists within a field are joined by |.
+func TabularRows( data interface{} ) ([]string, [][]string, error) {
+	value := reflect.ValueOf( data )
+	for value.Kind( ) == reflect.Ptr || value.Kind( ) == reflect.Interface {
+		if value.IsNil( ) {
+			return nil, nil, ErrNotTabular
+		}
+		value = value.Elem( )
+	}
+
+	items := []reflect.Value{ value }
+	itemType := value.Type( )
+	if value.Kind( ) == reflect.Slice || value.Kind( ) == reflect.Array {
+		itemType = value.Type( ).Elem( )
+		items = make( []reflect.Value, value.Len( ) )
+		for i := range items {
+			items[i] = value.Index( i )
+		}
+	}
+
+	for itemType.Kind( ) == reflect.Ptr {
+		itemType = itemType.Elem( )
+	}
+	if itemType.Kind( ) != reflect.Struct {
+		return nil, nil, ErrNotTabular
+	}
+
+	header, fields := tabularColumns( itemType )
+	rows := make( [][]string, 0, len(items) )
+	for _, item := range items {
+		item = reflect.Indirect( item )
+		row := make( []string, len(fields) )
+		if item.IsValid( ) {
+			for i, field := range fields {
+				row[i] = tabularValue( item.Field( field ))
+			}
+		}
+		rows = append( rows, row )
+	}
+
+	return header, rows, nil
+}
+
+// Find the column names and field indexes of a struct type
+func tabularColumns( t reflect.Type ) ([]string, []int) {
+	header := []string{}
+	fields := []int{}
+	for i := 0; i < t.NumField( ); i++ {
+		field := t.Field( i )
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		for _, tag := range []string{ "csv", "json" } {
+			tagName := strings.SplitN( field.Tag.Get( tag ), ",", 2 )[0]
+			if tagName != "" {
+				name = tagName
+				break
+			}
+		}
+		if name == "-" {
+			continue
+		}
+
+		header = append( header, name )
+		fields = append( fields, i )
+	}
+
+	return header, fields
+}
+
+// Format a single field value for tabular output
+func tabularValue( value reflect.Value ) (string) {
+	for value.Kind( ) == reflect.Ptr || value.Kind( ) == reflect.Interface {
+		if value.IsNil( ) {
+			return ""
+		}
+		value = value.Elem( )
+	}
+
+	if value.Kind( ) == reflect.Slice && value.Type( ).Elem( ).Kind( ) == reflect.Uint8 {
+		return string( value.Bytes( ))
+	}
+
+	if value.Kind( ) == reflect.Slice || value.Kind( ) == reflect.Array {
+		values := make( []string, value.Len( ) )
+		for i := range values {
+			values[i] = tabularValue( value.Index( i ))
+		}
+		return strings.Join( values, "|" )
+	}
+
+	return fmt.Sprint( value.Interface( ))
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header carrying the ID of a request, both
+// inbound from clients and on every response
+var RequestIDHeader = "X-Request-ID"
+
+// Longest inbound request ID accepted, longer
+// ones are replaced by a generated ID
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// Wrap a handler so every request has an ID, taken from
+// RequestIDHeader when the client sends a usable one and
+// generated otherwise. The ID is stored in the request context
+// and echoed in the response header, where the error helpers
+// find it to include it in their envelopes.
+func RequestIDHandler( next http.Handler ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		r = AssignRequestID( w.Header( ), r )
+		next.ServeHTTP( w, r )
+	})
+}
+
+// Give a request an ID, setting it on the response header
+// and returning the request with the ID in its context
+func AssignRequestID( header http.Header, r *http.Request ) (*http.Request) {
+	id := r.Header.Get( RequestIDHeader )
+	if !validRequestID( id ) {
+		id = NewRequestID( )
+	}
+
+	header.Set( RequestIDHeader, id )
+	ExposeHeaders( header, RequestIDHeader )
+	return r.WithContext( WithRequestID( r.Context( ), id ))
+}
+
+// Store a request ID in a context
+func WithRequestID( ctx context.Context, id string ) (context.Context) {
+	return context.WithValue( ctx, requestIDKey{}, id )
+}
+
+// Get the request ID stored in a context, or an empty string
+func RequestID( ctx context.Context ) (string) {
+	id, _ := ctx.Value( requestIDKey{} ).(string)
+	return id
+}
+
+// Generate a random request ID
+func NewRequestID( ) (string) {
+	id := make( []byte, 16 )
+	rand.Read( id )
+	return hex.EncodeToString( id )
+}
+
+// Check an inbound request ID is short and contains only
+// printable ascii, so it is safe to echo and to log
+func validRequestID( id string ) (bool) {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Find the request ID a response is for
+func responseRequestID( w http.ResponseWriter ) (string) {
+	return w.Header( ).Get( RequestIDHeader )
+}
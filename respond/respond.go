@@ -2,10 +2,14 @@
 // Use of this source code is governed by the MIT License
 // license that can be found in the LICENSE file.
 
+// Package respond formats and sends responses for net/http handlers.
+// Routers built on net/http such as chi use it directly, while other
+// frameworks adapt it by passing their response writer and request,
+// as respondgin does for gin. An echo handler would pass c.Response( )
+// and c.Request( ).
 package respond
 
 import (
-	"encoding/json"
 	"net/http"
 )
 
@@ -14,6 +18,7 @@ type JSONErrorResponse struct {
 	Status      int       	`json:"status"`
 	Detail		string		`json:"detail,omitempty"`
 	Issues		[]string  	`json:"issues,omitempty"`
+	RequestID	string		`json:"requestId,omitempty"`
 }
 
 type JSONSuccessResponse struct {
@@ -25,6 +30,14 @@ type JSONErrorDataResponse struct {
 	Status      int       	`json:"status"`
 	Detail		string		`json:"detail,omitempty"`
 	Data		interface{}	`json:"data,omitempty"`
+	RequestID	string		`json:"requestId,omitempty"`
+}
+
+type JSONMultiStatusResponse struct {
+	Message     string    	`json:"message"`
+	Status      int       	`json:"status"`
+	Results		interface{}	`json:"results"`
+	RequestID	string		`json:"requestId,omitempty"`
 }
 
 func RESPOK( w http.ResponseWriter ) {
@@ -46,24 +59,44 @@ func JSONData( w http.ResponseWriter, status int, data interface{} ) {
 // with appropriate error code
 func JSONError( w http.ResponseWriter, status int, message string ) {
 	err := JSONErrorResponse{ Status: status, Message: message }
-	JSONCode( w, status, err )
+	errorCode( w, status, err )
 }
 
 // Format result as an error message response and then send
 // with appropriate error code
 func JSONErrorWithIssues( w http.ResponseWriter, status int, message string, issues []string ) {
 	err := JSONErrorResponse{ Status: status, Message: message, Issues: issues }
-	JSONCode( w, status, err )
+	errorCode( w, status, err )
+}
+
+// Format an error as an error message response, including
+// its issues when the error provides a list of them
+func JSONErrorFromError( w http.ResponseWriter, status int, err error ) {
+	resp := JSONErrorResponse{ Status: status, Message: err.Error( ) }
+	if issuer, ok := err.(interface{ Issues( ) []string }); ok {
+		resp.Issues = issuer.Issues( )
+	}
+	errorCode( w, status, resp )
+}
+
+// Format a list of results for each entry in a batch
+// as a multi-status response
+func JSONMultiStatus( w http.ResponseWriter, message string, results interface{} ) {
+	resp := JSONMultiStatusResponse{ Status: http.StatusMultiStatus, Message: message, Results: results, RequestID: responseRequestID( w ) }
+	JSONCode( w, http.StatusMultiStatus, resp )
 }
 
 // Format response header and encode interface
 // for standardized json response
 func JSONCode( w http.ResponseWriter, status int, data interface{} ) {
-	w.Header( ).Set( "Content-Type", "application/json; charset=utf-8" )
-	w.Header( ).Set( "Access-Control-Allow-Origin", "*" )
+	writeJSON( w, status, "application/json; charset=utf-8", data )
+}
+
+// Set headers and encode interface as json
+// with the given content type
+func writeJSON( w http.ResponseWriter, status int, contentType string, data interface{} ) {
 	//w.Header( ).Set( "Connection", "close" )
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	WriteRendered( w, status, contentType, JSONRenderer{}, data )
 }
 
 // Format result as an error message response and then send
@@ -73,7 +106,7 @@ func JSONErrorWithData( w http.ResponseWriter, status int, message string, data
 	if detail != "" {
 		resp = JSONErrorDataResponse{ Status: status, Message: message, Detail: detail, Data: data }
 	}
-	JSONCode( w, status, resp )
+	errorCode( w, status, resp )
 }
 
 // Format Result as an error message response with an additional detail field
@@ -82,7 +115,7 @@ func JSONErrorWithDetail( w http.ResponseWriter, status int, message string, det
 	if detail != "" {
 		resp = JSONErrorResponse{ Status: status, Message: message, Detail: detail }
 	}
-	JSONCode( w, status, resp )
+	errorCode( w, status, resp )
 }
 
 // Shortcut to respond with a status code and wrapped data
@@ -97,8 +130,14 @@ func BYTEData( w http.ResponseWriter, status int, data []byte ) {
 
 // Output results as Bytes
 func BYTECode( w http.ResponseWriter, status int, data []byte ) {
-	w.Header( ).Set( "Content-Type", "application/json; charset=utf-8" )
-	w.Header( ).Set( "Access-Control-Allow-Origin", "*" )
+	BYTECodeWithType( w, status, "application/json; charset=utf-8", data )
+}
+
+// Output results as Bytes of the given content type, such
+// as preformatted tab delimited or PSI-MI XML records
+func BYTECodeWithType( w http.ResponseWriter, status int, contentType string, data []byte ) {
+	w.Header( ).Set( "Content-Type", contentType )
+	SetAllowOrigin( w.Header( ))
 	w.WriteHeader(status)
 	w.Write(data)
 }
\ No newline at end of file
@@ -0,0 +1,1191 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+	"github.com/BioGRID/biogrid-api-common/respond"
+	"github.com/BioGRID/biogrid-api-common/respond/respondtest"
+	"github.com/BioGRID/biogrid-api-common/testutils"
+)
+
+type issueError struct{}
+
+func (e issueError) Error( ) (string) {
+	return "Request failed validation."
+}
+
+func (e issueError) Issues( ) ([]string) {
+	return []string{ "name is a required field and cannot be empty" }
+}
+
+func TestRespond_ErrorFormats( t *testing.T ) {
+
+	var tests = []struct{
+		note     string
+		write    func( w http.ResponseWriter )
+		legacy   string
+		problem  string
+	} {
+		{
+			"Error",
+			func( w http.ResponseWriter ) { respond.JSONError( w, http.StatusNotFound, "Record not found." ) },
+			`{"message":"Record not found.","status":404}`,
+			`{"status":404,"title":"Record not found.","type":"about:blank"}`,
+		},
+		{
+			"Error with detail",
+			func( w http.ResponseWriter ) { respond.JSONErrorWithDetail( w, http.StatusBadRequest, "Invalid request.", "Missing geneList." ) },
+			`{"message":"Invalid request.","status":400,"detail":"Missing geneList."}`,
+			`{"detail":"Missing geneList.","status":400,"title":"Invalid request.","type":"about:blank"}`,
+		},
+		{
+			"Error with issues",
+			func( w http.ResponseWriter ) { respond.JSONErrorWithIssues( w, http.StatusBadRequest, "Request failed validation.", []string{ "a", "b" } ) },
+			`{"message":"Request failed validation.","status":400,"issues":["a","b"]}`,
+			`{"issues":["a","b"],"status":400,"title":"Request failed validation.","type":"about:blank"}`,
+		},
+		{
+			"Error from error",
+			func( w http.ResponseWriter ) { respond.JSONErrorFromError( w, http.StatusBadRequest, issueError{} ) },
+			`{"message":"Request failed validation.","status":400,"issues":["name is a required field and cannot be empty"]}`,
+			`{"issues":["name is a required field and cannot be empty"],"status":400,"title":"Request failed validation.","type":"about:blank"}`,
+		},
+		{
+			"Error from plain error",
+			func( w http.ResponseWriter ) { respond.JSONErrorFromError( w, http.StatusInternalServerError, errors.New( "Failed." )) },
+			`{"message":"Failed.","status":500}`,
+			`{"status":500,"title":"Failed.","type":"about:blank"}`,
+		},
+		{
+			"Error with data",
+			func( w http.ResponseWriter ) { respond.JSONErrorWithData( w, http.StatusConflict, "Conflict.", []int{ 1 }, "" ) },
+			`{"message":"Conflict.","status":409,"data":[1]}`,
+			`{"data":[1],"status":409,"title":"Conflict.","type":"about:blank"}`,
+		},
+	}
+
+	defer func( ) { respond.ErrorResponseFormat = respond.LegacyErrorFormat }( )
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		respond.ErrorResponseFormat = respond.LegacyErrorFormat
+		w := httptest.NewRecorder( )
+		test.write( w )
+		assert.Equal( t, "application/json; charset=utf-8", w.Header( ).Get( "Content-Type" ))
+		assert.JSONEq( t, test.legacy, w.Body.String( ))
+
+		respond.ErrorResponseFormat = respond.ProblemErrorFormat
+		w = httptest.NewRecorder( )
+		test.write( w )
+		assert.Equal( t, "application/problem+json", w.Header( ).Get( "Content-Type" ))
+		assert.JSONEq( t, test.problem, w.Body.String( ))
+	}
+
+}
+
+func TestRespond_Problem( t *testing.T ) {
+
+	w := httptest.NewRecorder( )
+	respond.Problem( w, respond.ProblemDetails{
+		Type: "https://wiki.thebiogrid.org/problems/unknown-organism",
+		Title: "Unknown organism",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "Taxonomy ID 1 is not supported.",
+		Instance: "/interactions",
+		Extensions: map[string]interface{}{ "taxId": 1, "title": "ignored" },
+	})
+
+	assert.Equal( t, http.StatusUnprocessableEntity, w.Code )
+	assert.Equal( t, "application/problem+json", w.Header( ).Get( "Content-Type" ))
+
+	var body map[string]interface{}
+	assert.Nil( t, json.Unmarshal( w.Body.Bytes( ), &body ))
+	assert.Equal( t, map[string]interface{}{
+		"type": "https://wiki.thebiogrid.org/problems/unknown-organism",
+		"title": "Unknown organism",
+		"status": float64(422),
+		"detail": "Taxonomy ID 1 is not supported.",
+		"instance": "/interactions",
+		"taxId": float64(1),
+	}, body )
+
+}
+
+type Interaction struct {
+	ID			uint64		`json:"id" xml:"id,attr"`
+	GeneA		string		`json:"geneA" csv:"Gene A"`
+	GeneB		string		`json:"geneB" csv:"Gene B"`
+	Throughput	[]string	`json:"throughput"`
+	Score		*float64	`json:"score"`
+	Internal	string		`json:"-" xml:"-"`
+}
+
+func TestRespond_Negotiate( t *testing.T ) {
+
+	score := 0.5
+	data := []Interaction{
+		{ ID: 1, GeneA: "CDC28", GeneB: "CLN2", Throughput: []string{ "Low", "High" }, Score: &score, Internal: "x" },
+		{ ID: 2, GeneA: "ACT1", GeneB: "MYO2\tB" },
+	}
+
+	var tests = []struct{
+		note         string
+		target       string
+		accept       string
+		status       int
+		contentType  string
+		body         string
+	} {
+		{ "No preference", "/", "", 200, "application/json; charset=utf-8", `[{"id":1,"geneA":"CDC28","geneB":"CLN2","throughput":["Low","High"],"score":0.5},{"id":2,"geneA":"ACT1","geneB":"MYO2\tB","throughput":null,"score":null}]` + "\n" },
+		{ "Accept tsv", "/", "text/tab-separated-values", 200, "text/tab-separated-values; charset=utf-8", "id\tGene A\tGene B\tthroughput\tscore\n1\tCDC28\tCLN2\tLow|High\t0.5\n2\tACT1\tMYO2 B\t\t\n" },
+		{ "Accept csv with quality", "/", "application/json;q=0.5, text/csv", 200, "text/csv; charset=utf-8", "id,Gene A,Gene B,throughput,score\n1,CDC28,CLN2,Low|High,0.5\n2,ACT1,MYO2\tB,,\n" },
+		{ "Accept xml", "/", "application/xml", 200, "application/xml; charset=utf-8", xmlHeader( ) + `<results><Interaction id="1"><GeneA>CDC28</GeneA><GeneB>CLN2</GeneB><Throughput>Low</Throughput><Throughput>High</Throughput><Score>0.5</Score></Interaction><Interaction id="2"><GeneA>ACT1</GeneA><GeneB>MYO2&#x9;B</GeneB></Interaction></results>` },
+		{ "Accept wildcard", "/", "text/html, */*;q=0.1", 200, "application/json; charset=utf-8", "" },
+		{ "Accept text wildcard", "/", "text/*", 200, "text/tab-separated-values; charset=utf-8", "" },
+		{ "Browser", "/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", 200, "application/json; charset=utf-8", "" },
+		{ "Wildcard tied with csv", "/", "text/csv, */*", 200, "application/json; charset=utf-8", "" },
+		{ "Wildcard below csv", "/", "text/csv, */*;q=0.5", 200, "text/csv; charset=utf-8", "" },
+		{ "Format parameter", "/?format=CSV", "application/json", 200, "text/csv; charset=utf-8", "" },
+		{ "Unknown format parameter", "/?format=pdf", "", 406, "application/json; charset=utf-8", "" },
+		{ "Unacceptable type", "/", "text/html, application/json;q=0", 406, "application/json; charset=utf-8", "" },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		r := httptest.NewRequest( "GET", test.target, nil )
+		if test.accept != "" {
+			r.Header.Set( "Accept", test.accept )
+		}
+		w := httptest.NewRecorder( )
+		respond.Negotiate( w, r, http.StatusOK, data )
+
+		assert.Equal( t, test.status, w.Code )
+		assert.Equal( t, test.contentType, w.Header( ).Get( "Content-Type" ))
+		assert.Equal( t, "Accept", w.Header( ).Get( "Vary" ))
+		if test.body != "" {
+			assert.Equal( t, test.body, w.Body.String( ))
+		}
+	}
+
+}
+
+func TestRespond_TabularRows( t *testing.T ) {
+
+	header, rows, err := respond.TabularRows( &Interaction{ ID: 3, GeneA: "A" } )
+	assert.Nil( t, err )
+	assert.Equal( t, []string{ "id", "Gene A", "Gene B", "throughput", "score" }, header )
+	assert.Equal( t, [][]string{{ "3", "A", "", "", "" }}, rows )
+
+	_, _, err = respond.TabularRows( []string{ "a" } )
+	assert.Equal( t, respond.ErrNotTabular, err )
+
+	w := httptest.NewRecorder( )
+	respond.Render( w, http.StatusOK, respond.CSVRenderer{}, map[string]int{ "a": 1 } )
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+
+}
+
+func xmlHeader( ) (string) {
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+}
+
+func testInteraction( ) (respond.InteractionRecord) {
+	return respond.InteractionRecord{
+		ID: 103,
+		InteractorA: respond.Interactor{
+			EntrezGeneID: "852457", BioGRIDID: "31623", SystematicName: "YLR362W", OfficialSymbol: "STE11",
+			Synonyms: []string{ "serine/threonine protein kinase STE11" }, TaxID: 559292, OrganismName: "Saccharomyces cerevisiae (S288c)",
+			SwissProt: []string{ "P23561" }, RefSeq: []string{ "NP_013466" },
+			ExperimentalRole: respond.MITerm{ ID: "MI:0496", Name: "bait" },
+			Stoichiometry: "2",
+		},
+		InteractorB: respond.Interactor{
+			EntrezGeneID: "851120", BioGRIDID: "31755", OfficialSymbol: "NBP2",
+			TaxID: 559292, OrganismName: "Saccharomyces cerevisiae (S288c)",
+		},
+		ExperimentalSystem: "Two-hybrid",
+		ExperimentalSystemType: "physical",
+		DetectionMethod: respond.MITerm{ ID: "MI:0018", Name: "two hybrid" },
+		InteractionType: respond.MITerm{ ID: "MI:0407", Name: "direct interaction" },
+		Author: "Mullins A (2005)",
+		PubMedID: "16179253",
+		Throughput: []string{ "Low Throughput" },
+		Qualifications: []string{ "interacts via the \"CH\" domain" },
+		SourceDatabase: "BIOGRID",
+		OntologyTerms: []respond.OntologyTerm{
+			{ ID: "APO:0000112", Name: "viable", Category: "phenotype", QualifierIDs: []string{ "APO:0000001", "APO:0000002" }, Type: "mutant" },
+			{ ID: "APO:0000113", Name: "inviable", Category: "phenotype" },
+		},
+		Created: time.Date( 2020, 4, 1, 0, 0, 0, 0, time.UTC ),
+	}
+}
+
+func TestRespond_MITAB25( t *testing.T ) {
+
+	var buf bytes.Buffer
+	err := respond.MITAB25Renderer{}.Render( &buf, []respond.InteractionRecord{ testInteraction( ) } )
+	assert.Nil( t, err )
+
+	lines := strings.Split( strings.TrimSuffix( buf.String( ), "\n" ), "\n" )
+	assert.Len( t, lines, 2 )
+	assert.True( t, strings.HasPrefix( lines[0], "#ID(s) interactor A\tID(s) interactor B\t" ))
+	assert.Len( t, strings.Split( lines[0], "\t" ), 15 )
+	assert.Equal( t, []string{
+		"entrez gene/locuslink:852457",
+		"entrez gene/locuslink:851120",
+		"biogrid:31623|entrez gene/locuslink:STE11|uniprot/swiss-prot:P23561|refseq:NP_013466",
+		"biogrid:31755|entrez gene/locuslink:NBP2",
+		"entrez gene/locuslink:YLR362W(gene name synonym)|entrez gene/locuslink:serine/threonine protein kinase STE11(gene name synonym)",
+		"-",
+		`psi-mi:"MI:0018"(two hybrid)`,
+		`"Mullins A (2005)"`,
+		"pubmed:16179253",
+		`taxid:559292("Saccharomyces cerevisiae (S288c)")`,
+		`taxid:559292("Saccharomyces cerevisiae (S288c)")`,
+		`psi-mi:"MI:0407"(direct interaction)`,
+		`psi-mi:"MI:0463"(biogrid)`,
+		"biogrid:103",
+		"-",
+	}, strings.Split( lines[1], "\t" ))
+
+}
+
+func TestRespond_MITAB27( t *testing.T ) {
+
+	var buf bytes.Buffer
+	err := respond.MITAB27Renderer{ NoHeader: true }.Render( &buf, testInteraction( ) )
+	assert.Nil( t, err )
+
+	columns := strings.Split( strings.TrimSuffix( buf.String( ), "\n" ), "\t" )
+	assert.Len( t, columns, 42 )
+	assert.Equal( t, "-", columns[15] )
+	assert.Equal( t, `psi-mi:"MI:0496"(bait)`, columns[18] )
+	assert.Equal( t, "-", columns[19] )
+	assert.Equal( t, `comment:"interacts via the \"CH\" domain"`, columns[27] )
+	assert.Equal( t, "2020/04/01", columns[30] )
+	assert.Equal( t, "false", columns[35] )
+	assert.Equal( t, "2", columns[38] )
+	assert.Equal( t, "-", columns[39] )
+
+}
+
+func TestRespond_BioGRIDTab3( t *testing.T ) {
+
+	var buf bytes.Buffer
+	record := testInteraction( )
+	err := respond.BioGRIDTab3Renderer{}.Render( &buf, []*respond.InteractionRecord{ &record, nil } )
+	assert.Nil( t, err )
+
+	lines := strings.Split( strings.TrimSuffix( buf.String( ), "\n" ), "\n" )
+	assert.Len( t, lines, 2 )
+	assert.True( t, strings.HasPrefix( lines[0], "#BioGRID Interaction ID\tEntrez Gene Interactor A\t" ))
+	assert.Len( t, strings.Split( lines[0], "\t" ), 37 )
+	assert.Equal( t, []string{
+		"103", "852457", "851120", "31623", "31755", "YLR362W", "-", "STE11", "NBP2",
+		"serine/threonine protein kinase STE11", "-",
+		"Two-hybrid", "physical", "Mullins A (2005)", "PUBMED:16179253", "559292", "559292",
+		"Low Throughput", "-", "-", `interacts via the "CH" domain`, "-", "BIOGRID",
+		"P23561", "-", "NP_013466", "-", "-", "-",
+		"APO:0000112|APO:0000113", "viable|inviable", "phenotype|phenotype",
+		"APO:0000001^APO:0000002|-", "-|-", "mutant",
+		"Saccharomyces cerevisiae (S288c)", "Saccharomyces cerevisiae (S288c)",
+	}, strings.Split( lines[1], "\t" ))
+
+	buf.Reset( )
+	err = respond.BioGRIDTab3Renderer{}.Render( &buf, []string{ "a" } )
+	assert.Equal( t, respond.ErrNotInteractions, err )
+
+}
+
+func TestRespond_NegotiateInteractions( t *testing.T ) {
+
+	respond.RegisterInteractionFormats( )
+
+	r := httptest.NewRequest( "GET", "/?format=mitab25", nil )
+	w := httptest.NewRecorder( )
+	respond.Negotiate( w, r, http.StatusOK, []respond.InteractionRecord{ testInteraction( ) } )
+	assert.Equal( t, http.StatusOK, w.Code )
+	assert.True( t, strings.HasPrefix( w.Body.String( ), "#ID(s) interactor A" ))
+
+	r = httptest.NewRequest( "GET", "/?format=tab3", nil )
+	w = httptest.NewRecorder( )
+	respond.Negotiate( w, r, http.StatusOK, []Interaction{} )
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+
+}
+
+type cancelRecorder struct {
+	*httptest.ResponseRecorder
+	flushes	int
+	cancel	context.CancelFunc
+	after	int
+}
+
+func (c *cancelRecorder) Flush( ) {
+	c.flushes++
+	if c.flushes == c.after {
+		c.cancel( )
+	}
+}
+
+func TestRespond_Stream( t *testing.T ) {
+
+	records := []Interaction{ { ID: 1, GeneA: "A" }, { ID: 2, GeneA: "B" } }
+
+	var tests = []struct{
+		note         string
+		stream       func( w http.ResponseWriter, r *http.Request, status int, records respond.RecordIterator ) error
+		records      respond.RecordIterator
+		status       int
+		contentType  string
+		body         string
+		err          bool
+	} {
+		{ "NDJSON", respond.StreamNDJSON, respond.SliceIterator( records ), 200, "application/x-ndjson", `{"id":1,"geneA":"A","geneB":"","throughput":null,"score":null}` + "\n" + `{"id":2,"geneA":"B","geneB":"","throughput":null,"score":null}` + "\n", false },
+		{ "JSON array", respond.StreamJSONArray, respond.SliceIterator( records ), 200, "application/json; charset=utf-8", `[{"id":1,"geneA":"A","geneB":"","throughput":null,"score":null},{"id":2,"geneA":"B","geneB":"","throughput":null,"score":null}]` + "\n", false },
+		{ "Empty JSON array", respond.StreamJSONArray, respond.SliceIterator( []int{} ), 200, "application/json; charset=utf-8", "[]\n", false },
+		{ "Empty NDJSON", respond.StreamNDJSON, respond.SliceIterator( []int{} ), 200, "application/x-ndjson", "", false },
+		{ "Channel", respond.StreamJSONArray, respond.ChannelIterator( testChannel( 1, 2, 3 )), 200, "application/json; charset=utf-8", "[1,2,3]\n", false },
+		{ "First record fails", respond.StreamJSONArray, failingIterator( 0 ), 500, "application/json; charset=utf-8", `{"message":"Unable to retrieve results.","status":500,"detail":"Lost connection."}` + "\n", true },
+		{ "Later record fails", respond.StreamJSONArray, failingIterator( 2 ), 200, "application/json; charset=utf-8", "[0,1", true },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		r := httptest.NewRequest( "GET", "/", nil )
+		w := httptest.NewRecorder( )
+		err := test.stream( w, r, http.StatusOK, test.records )
+
+		assert.Equal( t, test.err, err != nil )
+		assert.Equal( t, test.status, w.Code )
+		assert.Equal( t, test.contentType, w.Header( ).Get( "Content-Type" ))
+		assert.Equal( t, test.body, w.Body.String( ))
+	}
+
+}
+
+func TestRespond_StreamDisconnect( t *testing.T ) {
+
+	defer func( flushRecords int ) { respond.StreamFlushRecords = flushRecords }( respond.StreamFlushRecords )
+	respond.StreamFlushRecords = 2
+
+	ctx, cancel := context.WithCancel( context.Background( ))
+	r := httptest.NewRequest( "GET", "/", nil ).WithContext( ctx )
+	w := &cancelRecorder{ ResponseRecorder: httptest.NewRecorder( ), cancel: cancel, after: 2 }
+
+	records := make( chan int )
+	go func( ) {
+		for i := 0; ; i++ {
+			select {
+			case records <- i :
+			case <-ctx.Done( ) :
+				return
+			}
+		}
+	}( )
+
+	err := respond.StreamNDJSON( w, r, http.StatusOK, respond.ChannelIterator( records ))
+	assert.Equal( t, context.Canceled, err )
+	assert.Equal( t, "0\n1\n2\n", w.Body.String( ))
+
+}
+
+// A recorder that can be flushed from another goroutine
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	mu		sync.Mutex
+	flushes	int
+}
+
+func (f *flushCounter) Flush( ) {
+	f.mu.Lock( )
+	defer f.mu.Unlock( )
+	f.flushes++
+}
+
+func (f *flushCounter) count( ) (int) {
+	f.mu.Lock( )
+	defer f.mu.Unlock( )
+	return f.flushes
+}
+
+func TestRespond_StreamFlush( t *testing.T ) {
+
+	defer func( interval time.Duration ) { respond.StreamFlushInterval = interval }( respond.StreamFlushInterval )
+	respond.StreamFlushInterval = 10 * time.Millisecond
+
+	testutils.OutputTestNote( t, "First record flushed before the next is fetched" )
+	w := &flushCounter{ ResponseRecorder: httptest.NewRecorder( ) }
+	flushedFirst := false
+	next := 0
+	records := respond.IteratorFunc( func( ctx context.Context ) (interface{}, bool, error) {
+		next++
+		if next == 2 {
+			flushedFirst = w.count( ) == 1
+		}
+		return next, next <= 2, nil
+	})
+	err := respond.StreamJSONArray( w, httptest.NewRequest( "GET", "/", nil ), http.StatusOK, records )
+	assert.Nil( t, err )
+	assert.True( t, flushedFirst )
+	assert.Equal( t, "[1,2]\n", w.Body.String( ))
+
+	testutils.OutputTestNote( t, "Pending records flushed while waiting" )
+	w = &flushCounter{ ResponseRecorder: httptest.NewRecorder( ) }
+	flushedWaiting := false
+	next = 0
+	records = respond.IteratorFunc( func( ctx context.Context ) (interface{}, bool, error) {
+		next++
+		if next == 3 {
+			// The second record is pending until the timer flushes it
+			deadline := time.Now( ).Add( time.Second )
+			for w.count( ) < 2 && time.Now( ).Before( deadline ) {
+				time.Sleep( time.Millisecond )
+			}
+			flushedWaiting = w.count( ) == 2
+		}
+		return next, next <= 3, nil
+	})
+	err = respond.StreamNDJSON( w, httptest.NewRequest( "GET", "/", nil ), http.StatusOK, records )
+	assert.Nil( t, err )
+	assert.True( t, flushedWaiting )
+	assert.Equal( t, "1\n2\n3\n", w.Body.String( ))
+
+}
+
+func testChannel( values ...int ) (<-chan int) {
+	records := make( chan int, len(values) )
+	for _, value := range values {
+		records <- value
+	}
+	close( records )
+	return records
+}
+
+func failingIterator( after int ) (respond.RecordIterator) {
+	next := 0
+	return respond.IteratorFunc( func( ctx context.Context ) (interface{}, bool, error) {
+		if next == after {
+			return nil, false, errors.New( "Lost connection." )
+		}
+		next++
+		return next - 1, true, nil
+	})
+}
+
+func TestRespond_JSONPage( t *testing.T ) {
+
+	var tests = []struct{
+		note    string
+		target  string
+		page    respond.Page
+		link    string
+		body    string
+	} {
+		{
+			"Middle page",
+			"http://webservice.thebiogrid.org/interactions?geneList=STE11&start=10&max=10",
+			respond.Page{ Data: []int{ 1 }, Total: 35, Start: 10, Max: 10 },
+			`<http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=0>; rel="first", ` +
+				`<http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=0>; rel="prev", ` +
+				`<http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=20>; rel="next", ` +
+				`<http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=30>; rel="last"`,
+			`{"data":[1],"total":35,"start":10,"max":10,"next":"http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=20","prev":"http://webservice.thebiogrid.org/interactions?geneList=STE11&max=10&start=0"}`,
+		},
+		{
+			"Last page",
+			"http://webservice.thebiogrid.org/interactions?start=30&max=10",
+			respond.Page{ Data: []int{}, Total: 35, Start: 30, Max: 10 },
+			`<http://webservice.thebiogrid.org/interactions?max=10&start=0>; rel="first", ` +
+				`<http://webservice.thebiogrid.org/interactions?max=10&start=20>; rel="prev", ` +
+				`<http://webservice.thebiogrid.org/interactions?max=10&start=30>; rel="last"`,
+			`{"data":[],"total":35,"start":30,"max":10,"prev":"http://webservice.thebiogrid.org/interactions?max=10&start=20"}`,
+		},
+		{
+			"Unlimited page",
+			"http://webservice.thebiogrid.org/interactions",
+			respond.Page{ Data: []int{ 1, 2 }, Total: 2 },
+			"",
+			`{"data":[1,2],"total":2,"start":0,"max":0}`,
+		},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		r := httptest.NewRequest( "GET", test.target, nil )
+		w := httptest.NewRecorder( )
+		respond.JSONPage( w, r, http.StatusOK, test.page )
+
+		assert.Equal( t, http.StatusOK, w.Code )
+		assert.Equal( t, test.link, w.Header( ).Get( "Link" ))
+		assert.Equal( t, strconv.FormatUint( test.page.Total, 10 ), w.Header( ).Get( "X-Total-Count" ))
+		assert.JSONEq( t, test.body, w.Body.String( ))
+	}
+
+	defer func( ) { respond.TrustedProxies = []string{} }( )
+	respond.TrustedProxies = []string{ "10.0.0.0/8" }
+
+	var schemes = []struct{
+		remoteAddr  string
+		proto       string
+		expected    string
+		note        string
+	} {
+		{ "10.0.0.2:4000", "https", "https", "Trusted proxy" },
+		{ "10.0.0.2:4000", "HTTPS, http", "https", "Trusted proxy chain" },
+		{ "192.0.2.1:4000", "https", "http", "Untrusted client" },
+		{ "10.0.0.2:4000", "javascript", "http", "Unknown scheme" },
+	}
+
+	for _, test := range schemes {
+		testutils.OutputTestNote( t, test.note )
+		r := httptest.NewRequest( "GET", "http://webservice.thebiogrid.org/interactions", nil )
+		r.RemoteAddr = test.remoteAddr
+		r.Header.Set( "X-Forwarded-Proto", test.proto )
+		links := respond.PaginationLinks( r, respond.Page{ Total: 0, Max: 25 } )
+		assert.Equal( t, respond.PageLinks{
+			First: test.expected + "://webservice.thebiogrid.org/interactions?max=25&start=0",
+			Last: test.expected + "://webservice.thebiogrid.org/interactions?max=25&start=0",
+		}, links )
+	}
+
+}
+
+func TestRespond_CORSPolicy( t *testing.T ) {
+
+	var tests = []struct{
+		note     string
+		policy   respond.CORSPolicy
+		method   string
+		headers  map[string]string
+		status   int
+		expect   map[string]string
+	} {
+		{
+			"Any origin",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "*" }, ExposedHeaders: []string{ "X-Request-ID" } },
+			"GET", map[string]string{ "Origin": "https://example.org" },
+			200, map[string]string{ "Access-Control-Allow-Origin": "*", "Access-Control-Expose-Headers": "X-Request-ID", "Vary": "" },
+		},
+		{
+			"Exact origin with credentials",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "https://thebiogrid.org" }, AllowCredentials: true },
+			"GET", map[string]string{ "Origin": "https://thebiogrid.org" },
+			200, map[string]string{ "Access-Control-Allow-Origin": "https://thebiogrid.org", "Access-Control-Allow-Credentials": "true", "Vary": "Origin" },
+		},
+		{
+			"Wildcard origin pattern",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "https://*.thebiogrid.org" } },
+			"GET", map[string]string{ "Origin": "https://orcs.thebiogrid.org" },
+			200, map[string]string{ "Access-Control-Allow-Origin": "https://orcs.thebiogrid.org" },
+		},
+		{
+			"Origin not allowed",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "https://*.thebiogrid.org" } },
+			"GET", map[string]string{ "Origin": "https://thebiogrid.org.example.com" },
+			200, map[string]string{ "Access-Control-Allow-Origin": "" },
+		},
+		{
+			"No origin",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "*" } },
+			"GET", map[string]string{},
+			200, map[string]string{ "Access-Control-Allow-Origin": "" },
+		},
+		{
+			"Preflight",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "https://thebiogrid.org" }, AllowedHeaders: []string{ "Content-Type", "X-Access-Key" }, MaxAge: time.Hour },
+			"OPTIONS", map[string]string{ "Origin": "https://thebiogrid.org", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type, x-access-key" },
+			204, map[string]string{ "Access-Control-Allow-Origin": "https://thebiogrid.org", "Access-Control-Allow-Methods": "GET, HEAD, POST", "Access-Control-Allow-Headers": "content-type, x-access-key", "Access-Control-Max-Age": "3600" },
+		},
+		{
+			"Preflight with disallowed header",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "*" } },
+			"OPTIONS", map[string]string{ "Origin": "https://thebiogrid.org", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Secret" },
+			204, map[string]string{ "Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": "" },
+		},
+		{
+			"Preflight with disallowed method",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "*" } },
+			"OPTIONS", map[string]string{ "Origin": "https://thebiogrid.org", "Access-Control-Request-Method": "DELETE" },
+			204, map[string]string{ "Access-Control-Allow-Origin": "" },
+		},
+		{
+			"Plain OPTIONS request",
+			respond.CORSPolicy{ AllowedOrigins: []string{ "*" } },
+			"OPTIONS", map[string]string{ "Origin": "https://thebiogrid.org" },
+			200, map[string]string{ "Access-Control-Allow-Origin": "*" },
+		},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		handler := test.policy.Handler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+			respond.JSONOK( w, "ok" )
+		}))
+
+		r := httptest.NewRequest( test.method, "/", nil )
+		for name, value := range test.headers {
+			r.Header.Set( name, value )
+		}
+		w := httptest.NewRecorder( )
+		handler.ServeHTTP( w, r )
+
+		assert.Equal( t, test.status, w.Code )
+		for name, value := range test.expect {
+			assert.Equal( t, value, w.Header( ).Get( name ), name )
+		}
+	}
+
+	testutils.OutputTestNote( t, "Routes without a policy keep AllowOrigin" )
+	assert.Equal( t, "*", respond.AllowOrigin )
+	w := httptest.NewRecorder( )
+	respond.JSONOK( w, "ok" )
+	assert.Equal( t, "*", w.Header( ).Get( "Access-Control-Allow-Origin" ))
+
+	testutils.OutputTestNote( t, "Exposed headers kept from earlier middleware" )
+	policy := respond.CORSPolicy{ AllowedOrigins: []string{ "*" }, ExposedHeaders: []string{ "ETag", "x-request-id" } }
+	handler := respond.RequestIDHandler( policy.Handler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		respond.JSONOK( w, "ok" )
+	})))
+	r := httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "Origin", "https://example.org" )
+	w = httptest.NewRecorder( )
+	handler.ServeHTTP( w, r )
+	assert.Equal( t, "X-Request-ID, ETag", w.Header( ).Get( "Access-Control-Expose-Headers" ))
+
+}
+
+func TestRespond_Compress( t *testing.T ) {
+
+	large := strings.Repeat( "interaction ", 200 )
+	largeJSON := `"` + large + `"` + "\n"
+
+	var tests = []struct{
+		note            string
+		acceptEncoding  string
+		method          string
+		handler         func( w http.ResponseWriter, r *http.Request )
+		encoding        string
+		body            string
+	} {
+		{ "Gzip", "gzip, deflate", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "gzip", largeJSON },
+		{ "Deflate preferred by quality", "gzip;q=0.5, deflate", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "deflate", largeJSON },
+		{ "Wildcard", "*", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "gzip", largeJSON },
+		{ "Not accepted", "br", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "", largeJSON },
+		{ "Refused", "gzip;q=0", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "", largeJSON },
+		{ "Small body", "gzip", "GET", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, "ok" ) }, "", `"ok"` + "\n" },
+		{ "Compressed content type", "gzip", "GET", func( w http.ResponseWriter, r *http.Request ) {
+			w.Header( ).Set( "Content-Type", "image/png" )
+			w.Write( []byte( large ))
+		}, "", large },
+		{ "Head request", "gzip", "HEAD", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, large ) }, "", largeJSON },
+		{ "Streamed", "gzip", "GET", func( w http.ResponseWriter, r *http.Request ) {
+			respond.StreamNDJSON( w, r, http.StatusOK, respond.SliceIterator( []int{ 1, 2 } ))
+		}, "gzip", "1\n2\n" },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		r := httptest.NewRequest( test.method, "/", nil )
+		r.Header.Set( "Accept-Encoding", test.acceptEncoding )
+		w := httptest.NewRecorder( )
+		respond.Compress( http.HandlerFunc( test.handler )).ServeHTTP( w, r )
+
+		assert.Equal( t, http.StatusOK, w.Code )
+		assert.Equal( t, test.encoding, w.Header( ).Get( "Content-Encoding" ))
+		assert.Equal( t, "Accept-Encoding", w.Header( ).Get( "Vary" ))
+		assert.Equal( t, test.body, decodeBody( t, test.encoding, w.Body ))
+	}
+
+	testutils.OutputTestNote( t, "Cached responses get a weak ETag when compressed" )
+	policy := respond.CachePolicy{ MaxAge: time.Hour }
+	strongTag := policy.ETag( respond.JSONRenderer{}, []byte( largeJSON ))
+	cached := respond.Compress( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		respond.JSONCached( w, r, http.StatusOK, large, policy )
+	}))
+
+	r := httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "Accept-Encoding", "gzip" )
+	w := httptest.NewRecorder( )
+	cached.ServeHTTP( w, r )
+	assert.Equal( t, "gzip", w.Header( ).Get( "Content-Encoding" ))
+	assert.Equal( t, "W/" + strongTag, w.Header( ).Get( "ETag" ))
+
+	r = httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "Accept-Encoding", "gzip" )
+	r.Header.Set( "If-None-Match", "W/" + strongTag )
+	w = httptest.NewRecorder( )
+	cached.ServeHTTP( w, r )
+	assert.Equal( t, http.StatusNotModified, w.Code )
+	assert.Equal( t, "W/" + strongTag, w.Header( ).Get( "ETag" ))
+
+	r = httptest.NewRequest( "GET", "/", nil )
+	w = httptest.NewRecorder( )
+	cached.ServeHTTP( w, r )
+	assert.Equal( t, "", w.Header( ).Get( "Content-Encoding" ))
+	assert.Equal( t, strongTag, w.Header( ).Get( "ETag" ))
+
+	testutils.OutputTestNote( t, "Held back output dropped on a panic" )
+	defer func( logger *log.Logger ) { respond.PanicLogger = logger }( respond.PanicLogger )
+	respond.PanicLogger = log.New( io.Discard, "", 0 )
+	panicking := respond.RecoverHandler( respond.Compress( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		w.WriteHeader( http.StatusOK )
+		w.Write( []byte( "[1," ))
+		panic( "lost connection" )
+	})))
+	r = httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "Accept-Encoding", "gzip" )
+	w = httptest.NewRecorder( )
+	panicking.ServeHTTP( w, r )
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+	assert.NotContains( t, w.Body.String( ), "[1," )
+
+}
+
+func decodeBody( t *testing.T, encoding string, body io.Reader ) (string) {
+	var err error
+	switch encoding {
+	case "gzip" :
+		body, err = gzip.NewReader( body )
+		assert.Nil( t, err )
+	case "deflate" :
+		body, err = zlib.NewReader( body )
+		assert.Nil( t, err )
+	}
+
+	data, err := io.ReadAll( body )
+	assert.Nil( t, err )
+	return string( data )
+}
+
+func TestRespond_JSONCached( t *testing.T ) {
+
+	release := time.Date( 2020, 6, 1, 0, 0, 0, 0, time.UTC )
+	hashPolicy := respond.CachePolicy{ MaxAge: time.Hour, LastModified: release }
+	versionPolicy := respond.CachePolicy{ MaxAge: 24 * time.Hour, Version: "4.2.191", Immutable: true }
+	hashTag := hashPolicy.ETag( respond.JSONRenderer{}, []byte( `{"id":1}` + "\n" ))
+	versionTag := versionPolicy.ETag( respond.JSONRenderer{}, nil )
+
+	var tests = []struct{
+		note          string
+		method        string
+		policy        respond.CachePolicy
+		headers       map[string]string
+		status        int
+		etag          string
+		cacheControl  string
+	} {
+		{ "Hash ETag", "GET", hashPolicy, nil, 200, hashTag, "public, max-age=3600" },
+		{ "Matching ETag", "GET", hashPolicy, map[string]string{ "If-None-Match": hashTag }, 304, hashTag, "public, max-age=3600" },
+		{ "Matching weak ETag", "GET", hashPolicy, map[string]string{ "If-None-Match": `"abc", W/` + hashTag }, 304, hashTag, "public, max-age=3600" },
+		{ "Any ETag", "GET", hashPolicy, map[string]string{ "If-None-Match": "*" }, 304, hashTag, "public, max-age=3600" },
+		{ "Changed ETag", "GET", hashPolicy, map[string]string{ "If-None-Match": `"abc"`, "If-Modified-Since": release.Format( http.TimeFormat ) }, 200, hashTag, "public, max-age=3600" },
+		{ "Not modified since", "GET", hashPolicy, map[string]string{ "If-Modified-Since": release.Add( time.Hour ).Format( http.TimeFormat ) }, 304, hashTag, "public, max-age=3600" },
+		{ "Modified since", "GET", hashPolicy, map[string]string{ "If-Modified-Since": release.Add( -time.Hour ).Format( http.TimeFormat ) }, 200, hashTag, "public, max-age=3600" },
+		{ "Version ETag", "GET", versionPolicy, nil, 200, versionTag, "public, max-age=86400, immutable" },
+		{ "Matching version", "HEAD", versionPolicy, map[string]string{ "If-None-Match": versionTag }, 304, versionTag, "public, max-age=86400, immutable" },
+		{ "Not a GET request", "POST", versionPolicy, map[string]string{ "If-None-Match": versionTag }, 200, versionTag, "public, max-age=86400, immutable" },
+		{ "No store", "GET", respond.CachePolicy{ NoStore: true, Private: true }, nil, 200, hashTag, "no-store" },
+		{ "Private", "GET", respond.CachePolicy{ Private: true, NoCache: true }, nil, 200, hashTag, "private, no-cache, max-age=0" },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		r := httptest.NewRequest( test.method, "/", nil )
+		for name, value := range test.headers {
+			r.Header.Set( name, value )
+		}
+		w := httptest.NewRecorder( )
+		respond.JSONCached( w, r, http.StatusOK, map[string]int{ "id": 1 }, test.policy )
+
+		assert.Equal( t, test.status, w.Code )
+		assert.Equal( t, test.etag, w.Header( ).Get( "ETag" ))
+		assert.Equal( t, test.cacheControl, w.Header( ).Get( "Cache-Control" ))
+		if test.status == http.StatusNotModified {
+			assert.Equal( t, 0, w.Body.Len( ))
+		} else {
+			assert.Equal( t, `{"id":1}` + "\n", w.Body.String( ))
+		}
+		if !test.policy.LastModified.IsZero( ) {
+			assert.Equal( t, "Mon, 01 Jun 2020 00:00:00 GMT", w.Header( ).Get( "Last-Modified" ))
+		}
+	}
+
+	testutils.OutputTestNote( t, "Version ETags differ by format" )
+	assert.True( t, strings.HasPrefix( versionTag, `W/"4.2.191-` ))
+	tsvTag := versionPolicy.ETag( respond.TSVRenderer{}, nil )
+	mitabTag := versionPolicy.ETag( respond.MITAB25Renderer{}, nil )
+	assert.NotEqual( t, versionTag, tsvTag )
+	assert.NotEqual( t, tsvTag, mitabTag )
+	assert.NotEqual( t, mitabTag, versionPolicy.ETag( respond.MITAB25Renderer{ NoHeader: true }, nil ))
+
+	testutils.OutputTestNote( t, "Rendered version revalidation" )
+	data := []struct{ ID int `json:"id"` }{{ 1 }}
+	r := httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "If-None-Match", versionTag )
+	w := httptest.NewRecorder( )
+	respond.RenderCached( w, r, http.StatusOK, respond.TSVRenderer{}, data, versionPolicy )
+	assert.Equal( t, http.StatusOK, w.Code )
+	assert.Equal( t, tsvTag, w.Header( ).Get( "ETag" ))
+	assert.Equal( t, "Accept", w.Header( ).Get( "Vary" ))
+
+	r.Header.Set( "If-None-Match", tsvTag )
+	w = httptest.NewRecorder( )
+	respond.RenderCached( w, r, http.StatusOK, respond.TSVRenderer{}, data, versionPolicy )
+	assert.Equal( t, http.StatusNotModified, w.Code )
+	assert.Equal( t, "Accept", w.Header( ).Get( "Vary" ))
+
+	testutils.OutputTestNote( t, "Matching version on an error response" )
+	r = httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "If-None-Match", versionTag )
+	w = httptest.NewRecorder( )
+	respond.JSONCached( w, r, http.StatusNotFound, map[string]int{ "id": 1 }, versionPolicy )
+	assert.Equal( t, http.StatusNotFound, w.Code )
+	assert.Equal( t, `{"id":1}` + "\n", w.Body.String( ))
+
+}
+
+func TestRespond_EncodeFailure( t *testing.T ) {
+
+	defer func( hook func( error, interface{} ) ) { respond.EncodeErrorHook = hook }( respond.EncodeErrorHook )
+	var reported error
+	respond.EncodeErrorHook = func( err error, data interface{} ) {
+		reported = err
+	}
+
+	var tests = []struct{
+		note  string
+		data  interface{}
+	} {
+		{ "NaN score", map[string]float64{ "score": math.NaN( ) } },
+		{ "Channel field", struct{ Records chan int }{ make( chan int ) } },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		reported = nil
+		w := httptest.NewRecorder( )
+		w.Header( ).Set( "Content-Length", "512" )
+		respond.JSONOK( w, test.data )
+
+		assert.NotNil( t, reported )
+		assert.Equal( t, http.StatusInternalServerError, w.Code )
+		assert.Equal( t, "", w.Header( ).Get( "Content-Length" ))
+		assert.Equal( t, `{"message":"Unable to output results.","status":500}` + "\n", w.Body.String( ))
+	}
+
+	testutils.OutputTestNote( t, "Problem details format" )
+	defer func( ) { respond.ErrorResponseFormat = respond.LegacyErrorFormat }( )
+	respond.ErrorResponseFormat = respond.ProblemErrorFormat
+	w := httptest.NewRecorder( )
+	respond.JSONOK( w, math.Inf( 1 ))
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+	assert.Equal( t, "application/problem+json", w.Header( ).Get( "Content-Type" ))
+	assert.Equal( t, `{"status":500,"title":"Unable to output results.","type":"about:blank"}` + "\n", w.Body.String( ))
+
+}
+
+func TestRespond_JSONOptions( t *testing.T ) {
+
+	defer func( options respond.JSONEncoding ) { respond.JSONOptions = options }( respond.JSONOptions )
+	data := map[string]string{ "url": "<a href=\"x\">&</a>" }
+
+	w := httptest.NewRecorder( )
+	respond.JSONOK( w, data )
+	assert.Equal( t, `{"url":"\u003ca href=\"x\"\u003e\u0026\u003c/a\u003e"}` + "\n", w.Body.String( ))
+
+	respond.JSONOptions = respond.JSONEncoding{ Indent: "  " }
+	w = httptest.NewRecorder( )
+	respond.JSONOK( w, data )
+	assert.Equal( t, "{\n  \"url\": \"<a href=\\\"x\\\">&</a>\"\n}\n", w.Body.String( ))
+
+}
+
+func TestRespond_Conformance( t *testing.T ) {
+	respondtest.Run( t, respondtest.HTTPAdapter )
+}
+
+func TestRespond_RequestID( t *testing.T ) {
+
+	defer func( ) { respond.ErrorResponseFormat = respond.LegacyErrorFormat }( )
+
+	var tests = []struct{
+		note      string
+		inbound   string
+		format    respond.ErrorFormat
+		generated bool
+	} {
+		{ "Inbound ID", "abc-123", respond.LegacyErrorFormat, false },
+		{ "Generated ID", "", respond.LegacyErrorFormat, true },
+		{ "Unsafe inbound ID", "abc\n123", respond.LegacyErrorFormat, true },
+		{ "Overlong inbound ID", strings.Repeat( "a", 129 ), respond.LegacyErrorFormat, true },
+		{ "Problem details", "abc-123", respond.ProblemErrorFormat, false },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		respond.ErrorResponseFormat = test.format
+		var contextID string
+		handler := respond.RequestIDHandler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+			contextID = respond.RequestID( r.Context( ))
+			respond.JSONError( w, http.StatusNotFound, "Not found." )
+		}))
+
+		r := httptest.NewRequest( "GET", "/", nil )
+		if test.inbound != "" {
+			r.Header.Set( "X-Request-ID", test.inbound )
+		}
+		w := httptest.NewRecorder( )
+		handler.ServeHTTP( w, r )
+
+		id := w.Header( ).Get( "X-Request-ID" )
+		assert.Equal( t, contextID, id )
+		if test.generated {
+			assert.Len( t, id, 32 )
+		} else {
+			assert.Equal( t, test.inbound, id )
+		}
+		assert.Equal( t, "X-Request-ID", w.Header( ).Get( "Access-Control-Expose-Headers" ))
+
+		var body map[string]interface{}
+		assert.Nil( t, json.Unmarshal( w.Body.Bytes( ), &body ))
+		assert.Equal( t, id, body["requestId"] )
+	}
+
+	respond.ErrorResponseFormat = respond.LegacyErrorFormat
+	w := httptest.NewRecorder( )
+	respond.JSONError( w, http.StatusNotFound, "Not found." )
+	assert.Equal( t, `{"message":"Not found.","status":404}` + "\n", w.Body.String( ))
+	assert.Equal( t, "", respond.RequestID( context.Background( )))
+
+	testutils.OutputTestNote( t, "Multi status envelope" )
+	w = httptest.NewRecorder( )
+	w.Header( ).Set( "X-Request-ID", "abc-123" )
+	respond.JSONMultiStatus( w, "Partial.", []int{ 1 } )
+	assert.Equal( t, `{"message":"Partial.","status":207,"results":[1],"requestId":"abc-123"}` + "\n", w.Body.String( ))
+
+}
+
+func TestRespond_AccessLog( t *testing.T ) {
+
+	defer func( ) { respond.TrustedProxies = []string{} }( )
+
+	var tests = []struct{
+		note      string
+		logger    *respond.AccessLogger
+		proxies   []string
+		target    string
+		remote    string
+		headers   map[string]string
+		expect    map[string]interface{}
+	} {
+		{
+			"JSON entry",
+			&respond.AccessLogger{},
+			nil,
+			"/interactions/103?accessKey=abc&geneList=STE11",
+			"192.0.2.1:4000",
+			map[string]string{ "X-Request-ID": "req-1", "X-Forwarded-For": "198.51.100.7" },
+			map[string]interface{}{ "method": "GET", "path": "/interactions/103", "route": "/interactions/:id", "status": float64(404), "bytes": float64(38), "client_ip": "192.0.2.1", "request_id": "req-1", "access_key": "sha256:ba7816bf8f01" },
+		},
+		{
+			"Raw access key",
+			&respond.AccessLogger{ RawAccessKeys: true, AccessKeyParam: "key" },
+			nil,
+			"/interactions?key=abc",
+			"192.0.2.1:4000",
+			nil,
+			map[string]interface{}{ "access_key": "abc" },
+		},
+		{
+			"Trusted proxy",
+			&respond.AccessLogger{},
+			[]string{ "10.0.0.0/8", "192.0.2.1" },
+			"/interactions",
+			"192.0.2.1:4000",
+			map[string]string{ "X-Forwarded-For": "203.0.113.9, 198.51.100.7, 10.1.2.3" },
+			map[string]interface{}{ "route": "/interactions", "client_ip": "198.51.100.7" },
+		},
+		{
+			"Trusted proxy with real IP",
+			&respond.AccessLogger{},
+			[]string{ "192.0.2.1" },
+			"/interactions",
+			"192.0.2.1:4000",
+			map[string]string{ "X-Real-IP": "198.51.100.8" },
+			map[string]interface{}{ "client_ip": "198.51.100.8" },
+		},
+		{
+			"Custom route",
+			&respond.AccessLogger{ Route: func( r *http.Request ) string { return "/custom" } },
+			nil,
+			"/interactions/103",
+			"192.0.2.1:4000",
+			nil,
+			map[string]interface{}{ "route": "/custom" },
+		},
+		{
+			"Excluded path",
+			&respond.AccessLogger{ ExcludePaths: []string{ "/health" } },
+			nil,
+			"/health",
+			"192.0.2.1:4000",
+			nil,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		respond.TrustedProxies = test.proxies
+		var output bytes.Buffer
+		logger := test.logger
+		logger.Output = &output
+		handler := logger.Handler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+			respond.JSONError( w, http.StatusNotFound, "Not found." )
+		}))
+
+		r := httptest.NewRequest( "GET", test.target, nil )
+		r.RemoteAddr = test.remote
+		for name, value := range test.headers {
+			r.Header.Set( name, value )
+		}
+		handler.ServeHTTP( httptest.NewRecorder( ), r )
+
+		if test.expect == nil {
+			assert.Equal( t, "", output.String( ))
+			continue
+		}
+
+		var entry map[string]interface{}
+		assert.Nil( t, json.Unmarshal( output.Bytes( ), &entry ))
+		for key, value := range test.expect {
+			assert.Equal( t, value, entry[key], key )
+		}
+		assert.Contains( t, entry, "latency_ms" )
+		assert.Contains( t, entry, "time" )
+	}
+
+}
+
+func TestRespond_AccessLogFormats( t *testing.T ) {
+
+	var output bytes.Buffer
+	logger := &respond.AccessLogger{ Output: &output, Format: respond.LogfmtAccessLog }
+	logger.Write( respond.AccessLogEntry{
+		Time: "2020-06-01T00:00:00Z", Method: "GET", Path: "/a b", Route: "/a b",
+		Status: 200, Bytes: 12, LatencyMS: 1.5, ClientIP: "192.0.2.1",
+	})
+	assert.Equal( t, `time=2020-06-01T00:00:00Z method=GET path="/a b" route="/a b" status=200 bytes=12 latency_ms=1.5 client_ip=192.0.2.1` + "\n", output.String( ))
+
+	output.Reset( )
+	logger = &respond.AccessLogger{ Output: &output, SampleRate: 0.0001 }
+	for i := 0; i < 20; i++ {
+		logger.Write( respond.AccessLogEntry{} )
+	}
+	assert.Equal( t, 20, strings.Count( output.String( ), "\n" ))
+
+	output.Reset( )
+	handler := logger.Handler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		respond.JSONError( w, http.StatusInternalServerError, "Failed." )
+	}))
+	handler.ServeHTTP( httptest.NewRecorder( ), httptest.NewRequest( "GET", "/", nil ))
+	assert.Equal( t, 1, strings.Count( output.String( ), "\n" ))
+
+	output.Reset( )
+	handler = logger.Handler( http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		panic( "failed" )
+	}))
+	assert.Panics( t, func( ) { handler.ServeHTTP( httptest.NewRecorder( ), httptest.NewRequest( "GET", "/", nil )) } )
+	var entry respond.AccessLogEntry
+	assert.Nil( t, json.Unmarshal( output.Bytes( ), &entry ))
+	assert.Equal( t, http.StatusInternalServerError, entry.Status )
+
+}
+
+func TestRespond_Recover( t *testing.T ) {
+
+	defer func( logger *log.Logger ) { respond.PanicLogger = logger }( respond.PanicLogger )
+
+	var tests = []struct{
+		note     string
+		debug    string
+		handler  func( w http.ResponseWriter, r *http.Request )
+		status   int
+		detail   string
+		body     string
+	} {
+		{ "Panic", "", func( w http.ResponseWriter, r *http.Request ) { panic( "database password is hunter2" ) }, 500, "", `{"message":"An unexpected error occurred while processing the request.","status":500,"requestId":"abc-123"}` + "\n" },
+		{ "Panic with debug", "true", func( w http.ResponseWriter, r *http.Request ) { panic( "database password is hunter2" ) }, 500, "database password is hunter2\n", "" },
+		{ "Panic after writing", "", func( w http.ResponseWriter, r *http.Request ) {
+			w.WriteHeader( http.StatusOK )
+			w.Write( []byte( "[1," ))
+			panic( "lost connection" )
+		}, 200, "", "[1," },
+		{ "No panic", "", func( w http.ResponseWriter, r *http.Request ) { respond.JSONOK( w, 1 ) }, 200, "", "1\n" },
+	}
+
+	for _, test := range tests {
+		testutils.OutputTestNote( t, test.note )
+
+		var logged bytes.Buffer
+		respond.PanicLogger = log.New( &logged, "", 0 )
+		os.Setenv( respond.DebugEnvVar, test.debug )
+
+		handler := respond.RequestIDHandler( respond.RecoverHandler( http.HandlerFunc( test.handler )))
+		r := httptest.NewRequest( "GET", "/interactions", nil )
+		r.Header.Set( "X-Request-ID", "abc-123" )
+		w := httptest.NewRecorder( )
+		handler.ServeHTTP( w, r )
+
+		assert.Equal( t, test.status, w.Code )
+		if test.body != "" {
+			assert.Equal( t, test.body, w.Body.String( ))
+		}
+		if test.detail != "" {
+			var body respond.JSONErrorResponse
+			assert.Nil( t, json.Unmarshal( w.Body.Bytes( ), &body ))
+			assert.True( t, strings.HasPrefix( body.Detail, test.detail ))
+			assert.Contains( t, body.Detail, "goroutine" )
+		}
+		if test.note != "No panic" {
+			assert.True( t, strings.HasPrefix( logged.String( ), "panic serving GET /interactions [abc-123]: " ))
+			assert.Contains( t, logged.String( ), "goroutine" )
+		} else {
+			assert.Equal( t, "", logged.String( ))
+		}
+	}
+
+	os.Unsetenv( respond.DebugEnvVar )
+
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+// Package respondtest is a conformance suite checking that a framework
+// adapter for the respond package sends exactly what respond sends.
+package respondtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/BioGRID/biogrid-api-common/respond"
+	"github.com/BioGRID/biogrid-api-common/testutils"
+)
+
+// Responder sends responses for a single request
+// through the helpers of an adapter
+type Responder interface {
+	RESPOK( )
+	JSONOK( data interface{} )
+	JSONData( status int, data interface{} )
+	JSONCode( status int, data interface{} )
+	JSONError( status int, message string )
+	JSONErrorWithIssues( status int, message string, issues []string )
+	JSONErrorWithDetail( status int, message string, detail string )
+	JSONErrorWithData( status int, message string, data interface{}, detail string )
+	JSONErrorFromError( status int, err error )
+	JSONMultiStatus( message string, results interface{} )
+	BYTEOK( data []byte )
+	BYTEData( status int, data []byte )
+	BYTECodeWithType( status int, contentType string, data []byte )
+	Problem( problem respond.ProblemDetails )
+	Negotiate( status int, data interface{} )
+	JSONPage( status int, page respond.Page )
+	JSONCached( status int, data interface{}, policy respond.CachePolicy )
+	StreamJSONArray( status int, records respond.RecordIterator ) error
+	StreamNDJSON( status int, records respond.RecordIterator ) error
+}
+
+// Adapter builds a handler from the framework it adapts that
+// calls respond with a Responder for each request it serves
+type Adapter func( respond func( Responder ) ) http.Handler
+
+// Responder for plain net/http handlers
+type httpResponder struct {
+	w	http.ResponseWriter
+	r	*http.Request
+}
+
+// Adapter for plain net/http handlers, which every other adapter
+// must match
+func HTTPAdapter( handle func( Responder ) ) (http.Handler) {
+	return http.HandlerFunc( func( w http.ResponseWriter, r *http.Request ) {
+		handle( httpResponder{ w, r } )
+	})
+}
+
+func (h httpResponder) RESPOK( ) { respond.RESPOK( h.w ) }
+func (h httpResponder) JSONOK( data interface{} ) { respond.JSONOK( h.w, data ) }
+func (h httpResponder) JSONData( status int, data interface{} ) { respond.JSONData( h.w, status, data ) }
+func (h httpResponder) JSONCode( status int, data interface{} ) { respond.JSONCode( h.w, status, data ) }
+func (h httpResponder) JSONError( status int, message string ) { respond.JSONError( h.w, status, message ) }
+func (h httpResponder) JSONErrorWithIssues( status int, message string, issues []string ) { respond.JSONErrorWithIssues( h.w, status, message, issues ) }
+func (h httpResponder) JSONErrorWithDetail( status int, message string, detail string ) { respond.JSONErrorWithDetail( h.w, status, message, detail ) }
+func (h httpResponder) JSONErrorWithData( status int, message string, data interface{}, detail string ) { respond.JSONErrorWithData( h.w, status, message, data, detail ) }
+func (h httpResponder) JSONErrorFromError( status int, err error ) { respond.JSONErrorFromError( h.w, status, err ) }
+func (h httpResponder) JSONMultiStatus( message string, results interface{} ) { respond.JSONMultiStatus( h.w, message, results ) }
+func (h httpResponder) BYTEOK( data []byte ) { respond.BYTEOK( h.w, data ) }
+func (h httpResponder) BYTEData( status int, data []byte ) { respond.BYTEData( h.w, status, data ) }
+func (h httpResponder) BYTECodeWithType( status int, contentType string, data []byte ) { respond.BYTECodeWithType( h.w, status, contentType, data ) }
+func (h httpResponder) Problem( problem respond.ProblemDetails ) { respond.Problem( h.w, problem ) }
+func (h httpResponder) Negotiate( status int, data interface{} ) { respond.Negotiate( h.w, h.r, status, data ) }
+func (h httpResponder) JSONPage( status int, page respond.Page ) { respond.JSONPage( h.w, h.r, status, page ) }
+func (h httpResponder) JSONCached( status int, data interface{}, policy respond.CachePolicy ) { respond.JSONCached( h.w, h.r, status, data, policy ) }
+func (h httpResponder) StreamJSONArray( status int, records respond.RecordIterator ) error { return respond.StreamJSONArray( h.w, h.r, status, records ) }
+func (h httpResponder) StreamNDJSON( status int, records respond.RecordIterator ) error { return respond.StreamNDJSON( h.w, h.r, status, records ) }
+
+type issueError struct{}
+
+func (issueError) Error( ) (string) {
+	return "Request failed validation."
+}
+
+func (issueError) Issues( ) ([]string) {
+	return []string{ "id is a required field" }
+}
+
+const jsonType = "application/json; charset=utf-8"
+
+var versionTag = respond.CachePolicy{ Version: "1" }.ETag( respond.JSONRenderer{}, nil )
+
+// A single conformance case
+type conformanceCase struct {
+	note			string
+	target			string
+	headers			map[string]string
+	respond			func( Responder )
+	status			int
+	contentType		string
+	expectHeaders	map[string]string
+	body			string
+}
+
+var cases = []conformanceCase{
+	{ "RESPOK", "/", nil, func( r Responder ) { r.RESPOK( ) }, 200, "", nil, "" },
+	{ "JSONOK", "/", nil, func( r Responder ) { r.JSONOK( map[string]int{ "id": 1 } ) }, 200, jsonType, map[string]string{ "Access-Control-Allow-Origin": "*" }, `{"id":1}` + "\n" },
+	{ "JSONData", "/", nil, func( r Responder ) { r.JSONData( 201, []int{ 1 } ) }, 201, jsonType, map[string]string{ "Access-Control-Allow-Origin": "*" }, `{"data":[1]}` + "\n" },
+	{ "JSONCode", "/", nil, func( r Responder ) { r.JSONCode( 202, "queued" ) }, 202, jsonType, nil, `"queued"` + "\n" },
+	{ "JSONError", "/", nil, func( r Responder ) { r.JSONError( 404, "Not found." ) }, 404, jsonType, map[string]string{ "Access-Control-Allow-Origin": "*" }, `{"message":"Not found.","status":404}` + "\n" },
+	{ "JSONErrorWithIssues", "/", nil, func( r Responder ) { r.JSONErrorWithIssues( 400, "Invalid.", []string{ "a" } ) }, 400, jsonType, nil, `{"message":"Invalid.","status":400,"issues":["a"]}` + "\n" },
+	{ "JSONErrorWithDetail", "/", nil, func( r Responder ) { r.JSONErrorWithDetail( 400, "Invalid.", "Missing id." ) }, 400, jsonType, nil, `{"message":"Invalid.","status":400,"detail":"Missing id."}` + "\n" },
+	{ "JSONErrorWithData", "/", nil, func( r Responder ) { r.JSONErrorWithData( 409, "Conflict.", []int{ 2 }, "" ) }, 409, jsonType, nil, `{"message":"Conflict.","status":409,"data":[2]}` + "\n" },
+	{ "JSONErrorFromError", "/", nil, func( r Responder ) { r.JSONErrorFromError( 400, issueError{} ) }, 400, jsonType, nil, `{"message":"Request failed validation.","status":400,"issues":["id is a required field"]}` + "\n" },
+	{ "JSONErrorFromError plain", "/", nil, func( r Responder ) { r.JSONErrorFromError( 500, errors.New( "Failed." )) }, 500, jsonType, nil, `{"message":"Failed.","status":500}` + "\n" },
+	{ "JSONMultiStatus", "/", nil, func( r Responder ) { r.JSONMultiStatus( "Partial.", []int{ 1 } ) }, 207, jsonType, nil, `{"message":"Partial.","status":207,"results":[1]}` + "\n" },
+	{ "BYTEOK writes raw bytes", "/", nil, func( r Responder ) { r.BYTEOK( []byte( `{"id":1}` )) }, 200, jsonType, map[string]string{ "Access-Control-Allow-Origin": "*" }, `{"id":1}` },
+	{ "BYTEData", "/", nil, func( r Responder ) { r.BYTEData( 201, []byte( `[]` )) }, 201, jsonType, nil, `[]` },
+	{ "BYTECodeWithType", "/", nil, func( r Responder ) { r.BYTECodeWithType( 200, "text/tab-separated-values; charset=utf-8", []byte( "id\n1\n" )) }, 200, "text/tab-separated-values; charset=utf-8", map[string]string{ "Access-Control-Allow-Origin": "*" }, "id\n1\n" },
+	{ "Problem", "/", nil, func( r Responder ) { r.Problem( respond.ProblemDetails{ Title: "Gone.", Status: 410, Instance: "/x" } ) }, 410, "application/problem+json", nil, `{"instance":"/x","status":410,"title":"Gone.","type":"about:blank"}` + "\n" },
+	{ "Problem without instance", "/interactions/1", nil, func( r Responder ) { r.Problem( respond.ProblemDetails{ Title: "Gone.", Status: 410 } ) }, 410, "application/problem+json", nil, `{"status":410,"title":"Gone.","type":"about:blank"}` + "\n" },
+	{ "Negotiate tsv", "/?format=tsv", nil, func( r Responder ) { r.Negotiate( 200, []struct{ ID int `json:"id"` }{{ 1 }} ) }, 200, "text/tab-separated-values; charset=utf-8", map[string]string{ "Vary": "Accept" }, "id\n1\n" },
+	{ "Negotiate not acceptable", "/", map[string]string{ "Accept": "image/png" }, func( r Responder ) { r.Negotiate( 200, 1 ) }, 406, jsonType, nil, "" },
+	{ "JSONPage", "/list?max=1", nil, func( r Responder ) { r.JSONPage( 200, respond.Page{ Data: []int{ 1 }, Total: 2, Max: 1 } ) }, 200, jsonType, map[string]string{ "X-Total-Count": "2" }, `{"data":[1],"total":2,"start":0,"max":1,"next":"http://example.com/list?max=1\u0026start=1"}` + "\n" },
+	{ "JSONCached", "/", map[string]string{ "If-None-Match": versionTag }, func( r Responder ) { r.JSONCached( 200, 1, respond.CachePolicy{ Version: "1" } ) }, 304, "", map[string]string{ "ETag": versionTag }, "" },
+	{ "StreamJSONArray", "/", nil, func( r Responder ) { r.StreamJSONArray( 200, respond.SliceIterator( []int{ 1, 2 } )) }, 200, jsonType, nil, "[1,2]\n" },
+	{ "StreamNDJSON", "/", nil, func( r Responder ) { r.StreamNDJSON( 200, respond.SliceIterator( []int{ 1, 2 } )) }, 200, "application/x-ndjson", nil, "1\n2\n" },
+}
+
+// Run every conformance case against an adapter, checking each
+// response matches what plain net/http handlers send
+func Run( t *testing.T, adapter Adapter ) {
+	for _, test := range cases {
+		testutils.OutputTestNote( t, test.note )
+
+		expected := serve( HTTPAdapter, test )
+		actual := serve( adapter, test )
+
+		assert.Equal( t, test.status, expected.Code, test.note )
+		assert.Equal( t, test.contentType, expected.Header( ).Get( "Content-Type" ), test.note )
+		for name, value := range test.expectHeaders {
+			assert.Equal( t, value, expected.Header( ).Get( name ), test.note )
+		}
+		if test.body != "" {
+			assert.Equal( t, test.body, expected.Body.String( ), test.note )
+		}
+
+		assert.Equal( t, expected.Code, actual.Code, test.note )
+		assert.Equal( t, expected.Header( ), actual.Header( ), test.note )
+		assert.Equal( t, expected.Body.String( ), actual.Body.String( ), test.note )
+	}
+}
+
+// Serve a conformance case through an adapter
+func serve( adapter Adapter, test conformanceCase ) (*httptest.ResponseRecorder) {
+	r := httptest.NewRequest( "GET", test.target, nil )
+	for name, value := range test.headers {
+		r.Header.Set( name, value )
+	}
+	w := httptest.NewRecorder( )
+	adapter( test.respond ).ServeHTTP( w, r )
+	return w
+}
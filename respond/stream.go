@@ -0,0 +1,214 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Number of records written by a stream between flushes
+var StreamFlushRecords = 100
+
+// Longest time a stream holds written records before flushing,
+// even while it waits for the next record
+var StreamFlushInterval = time.Second
+
+// Source of records for a streaming response. Next returns false
+// once there are no more records, or an error to end the stream.
+// Next should give up and return the context error once ctx is done.
+type RecordIterator interface {
+	Next( ctx context.Context ) (interface{}, bool, error)
+}
+
+// Use a function as a RecordIterator
+type IteratorFunc func( ctx context.Context ) (interface{}, bool, error)
+
+func (f IteratorFunc) Next( ctx context.Context ) (interface{}, bool, error) {
+	return f( ctx )
+}
+
+// Iterate over the records sent on a channel until it is closed
+func ChannelIterator[T any]( records <-chan T ) (RecordIterator) {
+	return IteratorFunc( func( ctx context.Context ) (interface{}, bool, error) {
+		select {
+		case record, ok := <-records :
+			return record, ok, nil
+		case <-ctx.Done( ) :
+			return nil, false, ctx.Err( )
+		}
+	})
+}
+
+// Iterate over the records in a slice
+func SliceIterator[T any]( records []T ) (RecordIterator) {
+	next := 0
+	return IteratorFunc( func( ctx context.Context ) (interface{}, bool, error) {
+		if next >= len(records) {
+			return nil, false, nil
+		}
+		next++
+		return records[next-1], true, nil
+	})
+}
+
+// Stream records as newline delimited json, one record per line.
+// An error from the first record gets a 500 error response, while
+// later errors and client disconnects end the stream early and
+// are returned.
+func StreamNDJSON( w http.ResponseWriter, r *http.Request, status int, records RecordIterator ) (error) {
+	return stream( w, r, status, "application/x-ndjson", records, false )
+}
+
+// Stream records as a single json array. An error from the first
+// record gets a 500 error response, while later errors and client
+// disconnects end the stream early and are returned, leaving the
+// array unclosed so clients can tell the output is incomplete.
+func StreamJSONArray( w http.ResponseWriter, r *http.Request, status int, records RecordIterator ) (error) {
+	return stream( w, r, status, "application/json; charset=utf-8", records, true )
+}
+
+// Write each record as json, either on its own line or as an
+// entry in a json array. The first record is flushed as soon as
+// it is written, later ones in batches of StreamFlushRecords or
+// once StreamFlushInterval has passed.
+func stream( w http.ResponseWriter, r *http.Request, status int, contentType string, records RecordIterator, array bool ) (error) {
+	ctx := r.Context( )
+
+	// Fetch the first record before sending headers so
+	// an immediate failure can still get an error response
+	record, ok, err := records.Next( ctx )
+	if err != nil {
+		if ctx.Err( ) == nil {
+			JSONErrorWithDetail( w, http.StatusInternalServerError, "Unable to retrieve results.", err.Error( ) )
+		}
+		return err
+	}
+
+	w.Header( ).Set( "Content-Type", contentType )
+	SetAllowOrigin( w.Header( ))
+	w.Header( ).Set( "X-Content-Type-Options", "nosniff" )
+	w.WriteHeader( status )
+
+	out := &streamWriter{ w: w }
+	out.flusher, _ = w.(http.Flusher)
+	defer out.close( )
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder( &buf )
+	encoder.SetEscapeHTML( JSONOptions.EscapeHTML )
+	if array {
+		buf.WriteString( "[" )
+	}
+
+	for count := 0; ok; count++ {
+		if array && count > 0 {
+			buf.WriteString( "," )
+		}
+		if err := encoder.Encode( record ); err != nil {
+			if EncodeErrorHook != nil {
+				EncodeErrorHook( err, record )
+			}
+			return err
+		}
+
+		// The encoder always ends a value with a newline, which
+		// separates ndjson records but is dropped within an array
+		if array {
+			buf.Truncate( buf.Len( ) - 1 )
+		}
+
+		if err := out.writeRecord( buf.Bytes( ), count == 0 ); err != nil {
+			return err
+		}
+		buf.Reset( )
+
+		if err := ctx.Err( ); err != nil {
+			return err
+		}
+
+		record, ok, err = records.Next( ctx )
+		if err != nil {
+			return err
+		}
+	}
+
+	if array {
+		buf.WriteString( "]\n" )
+	}
+	return out.write( buf.Bytes( ))
+}
+
+// Writes the records of a stream, flushing them in batches and
+// from a timer while the stream waits for its next record
+type streamWriter struct {
+	w			http.ResponseWriter
+	flusher		http.Flusher
+	mu			sync.Mutex
+	timer		*time.Timer
+	pending		int
+	closed		bool
+}
+
+// Write data without counting it as a record
+func (s *streamWriter) write( data []byte ) (error) {
+	s.mu.Lock( )
+	defer s.mu.Unlock( )
+	_, err := s.w.Write( data )
+	return err
+}
+
+// Write a record, flushing it straight away if asked or once
+// enough are pending, or else making sure a flush is scheduled
+func (s *streamWriter) writeRecord( data []byte, flush bool ) (error) {
+	s.mu.Lock( )
+	defer s.mu.Unlock( )
+	if _, err := s.w.Write( data ); err != nil {
+		return err
+	}
+
+	s.pending++
+	if flush || s.pending >= StreamFlushRecords {
+		s.flush( )
+	} else if s.timer == nil {
+		s.timer = time.AfterFunc( StreamFlushInterval, s.flushPending )
+	}
+	return nil
+}
+
+// Flush records still pending when the flush timer fires
+func (s *streamWriter) flushPending( ) {
+	s.mu.Lock( )
+	defer s.mu.Unlock( )
+	s.timer = nil
+	if !s.closed && s.pending > 0 {
+		s.flush( )
+	}
+}
+
+// Flush the response, which the caller must hold the lock for
+func (s *streamWriter) flush( ) {
+	if s.timer != nil {
+		s.timer.Stop( )
+		s.timer = nil
+	}
+	s.pending = 0
+	if s.flusher != nil {
+		s.flusher.Flush( )
+	}
+}
+
+// Flush whatever was written and stop the flush timer, so the
+// response is never touched once the stream returns
+func (s *streamWriter) close( ) {
+	s.mu.Lock( )
+	defer s.mu.Unlock( )
+	s.flush( )
+	s.closed = true
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"net/http"
+	"time"
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Middleware logging each request through an access logger,
+// using the matched gin route as the normalized route. Like
+// respond.AccessLogger.Handler, a panic is logged as a 500.
+func AccessLog( logger *respond.AccessLogger ) (gin.HandlerFunc) {
+	return func( c *gin.Context ) {
+		start := time.Now( )
+		defer func( ) {
+			if recovered := recover( ); recovered != nil {
+				logger.Record( c.Request, c.Writer.Header( ), c.FullPath( ), http.StatusInternalServerError, responseSize( c ), time.Since( start ))
+				panic( recovered )
+			}
+		}( )
+		c.Next( )
+
+		logger.Record( c.Request, c.Writer.Header( ), c.FullPath( ), c.Writer.Status( ), responseSize( c ), time.Since( start ))
+	}
+}
+
+// Number of body bytes written, which gin
+// reports as -1 before anything is written
+func responseSize( c *gin.Context ) (int64) {
+	size := int64( c.Writer.Size( ))
+	if size < 0 {
+		return 0
+	}
+	return size
+}
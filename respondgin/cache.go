@@ -0,0 +1,16 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Respond with data as json under a cache policy, answering
+// with 304 Not Modified if the client already holds it
+func JSONCached( c *gin.Context, status int, data interface{}, policy respond.CachePolicy ) {
+	respond.JSONCached( c.Writer, c.Request, status, data, policy )
+}
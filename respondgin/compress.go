@@ -0,0 +1,57 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// A gin response writer that sends its output
+// through a respond.CompressWriter
+type compressWriter struct {
+	gin.ResponseWriter
+	compressor	*respond.CompressWriter
+}
+
+func (w *compressWriter) WriteHeader( status int ) {
+	w.compressor.WriteHeader( status )
+	w.ResponseWriter.WriteHeader( status )
+}
+
+func (w *compressWriter) WriteHeaderNow( ) {
+	w.compressor.WriteHeaderNow( )
+}
+
+func (w *compressWriter) Write( data []byte ) (int, error) {
+	return w.compressor.Write( data )
+}
+
+func (w *compressWriter) WriteString( s string ) (int, error) {
+	return w.compressor.Write( []byte( s ))
+}
+
+func (w *compressWriter) Flush( ) {
+	w.compressor.Flush( )
+}
+
+// Middleware compressing responses with the encoding
+// a client prefers from its Accept-Encoding header. Like
+// respond.Compress, held back output is dropped on a panic.
+func Compress( ) (gin.HandlerFunc) {
+	return func( c *gin.Context ) {
+		writer := c.Writer
+		compressor := respond.NewCompressWriter( writer, c.Request )
+		c.Writer = &compressWriter{ ResponseWriter: writer, compressor: compressor }
+		defer func( ) {
+			c.Writer = writer
+			if recovered := recover( ); recovered != nil {
+				panic( recovered )
+			}
+			compressor.Close( )
+		}( )
+		c.Next( )
+	}
+}
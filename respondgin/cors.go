@@ -0,0 +1,70 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"net/http"
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// A gin response writer that restores the Access-Control-Allow-Origin
+// a policy decided on when the response starts
+type corsWriter struct {
+	gin.ResponseWriter
+	origin		string
+	wroteHeader	bool
+}
+
+func (w *corsWriter) WriteHeader( status int ) {
+	w.restoreOrigin( )
+	w.ResponseWriter.WriteHeader( status )
+}
+
+func (w *corsWriter) WriteHeaderNow( ) {
+	w.restoreOrigin( )
+	w.ResponseWriter.WriteHeaderNow( )
+}
+
+func (w *corsWriter) Write( data []byte ) (int, error) {
+	w.restoreOrigin( )
+	return w.ResponseWriter.Write( data )
+}
+
+func (w *corsWriter) WriteString( s string ) (int, error) {
+	w.restoreOrigin( )
+	return w.ResponseWriter.WriteString( s )
+}
+
+func (w *corsWriter) Flush( ) {
+	w.restoreOrigin( )
+	w.ResponseWriter.Flush( )
+}
+
+func (w *corsWriter) restoreOrigin( ) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	respond.RestoreAllowOrigin( w.Header( ), w.origin )
+}
+
+// Middleware applying a CORS policy to every request, with
+// preflight requests answered directly with 204 No Content
+func CORS( policy *respond.CORSPolicy ) (gin.HandlerFunc) {
+	return func( c *gin.Context ) {
+		if policy.Apply( c.Writer.Header( ), c.Request ) {
+			c.AbortWithStatus( http.StatusNoContent )
+			return
+		}
+
+		writer := c.Writer
+		c.Writer = &corsWriter{ ResponseWriter: writer, origin: writer.Header( ).Get( "Access-Control-Allow-Origin" ) }
+		defer func( ) {
+			c.Writer = writer
+		}( )
+		c.Next( )
+	}
+}
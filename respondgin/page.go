@@ -0,0 +1,18 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Respond with a page of results wrapped in a paginated envelope,
+// along with Link and X-Total-Count headers describing the pages
+func JSONPage( c *gin.Context, status int, page respond.Page ) {
+	links := respond.PaginationLinks( c.Request, page )
+	respond.SetPaginationHeaders( c.Writer.Header( ), page, links )
+	JSONCode( c, status, respond.PaginatedResponse( page, links ))
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Error helpers use the format chosen by respond.ErrorResponseFormat
+type ErrorFormat = respond.ErrorFormat
+type ProblemDetails = respond.ProblemDetails
+
+const (
+	LegacyErrorFormat = respond.LegacyErrorFormat
+	ProblemErrorFormat = respond.ProblemErrorFormat
+)
+
+// Send a problem details response with its own status code
+func Problem( c *gin.Context, problem ProblemDetails ) {
+	respond.Problem( c.Writer, problem )
+}
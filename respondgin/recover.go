@@ -0,0 +1,31 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"net/http"
+	"runtime/debug"
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Middleware logging a panic while serving a request and answering
+// it with a 500 error response instead of dropping the connection.
+// The panic is only described in the response when the environment
+// variable named by respond.DebugEnvVar is true.
+func Recover( ) (gin.HandlerFunc) {
+	return func( c *gin.Context ) {
+		defer func( ) {
+			if recovered := recover( ); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic( recovered )
+				}
+				respond.HandlePanic( c.Writer, c.Request, recovered, debug.Stack( ), c.Writer.Written( ))
+				c.Abort( )
+			}
+		}( )
+		c.Next( )
+	}
+}
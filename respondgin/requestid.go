@@ -0,0 +1,26 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Key under which the request ID is stored in the gin context
+const RequestIDKey = "requestId"
+
+// Middleware giving every request an ID, taken from the
+// X-Request-ID header when the client sends a usable one and
+// generated otherwise. The ID is stored in the request context and
+// the gin context, and echoed in the response header, where the
+// error helpers find it to include it in their envelopes.
+func RequestID( ) (gin.HandlerFunc) {
+	return func( c *gin.Context ) {
+		c.Request = respond.AssignRequestID( c.Writer.Header( ), c.Request )
+		c.Set( RequestIDKey, respond.RequestID( c.Request.Context( )))
+		c.Next( )
+	}
+}
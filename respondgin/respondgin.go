@@ -2,101 +2,103 @@
 // Use of this source code is governed by the MIT License
 // license that can be found in the LICENSE file.
 
+// Package respondgin adapts the respond package to gin handlers. Every
+// helper sends exactly what its respond counterpart sends, using the
+// gin context's response writer and request.
 package respondgin
 
 import (
-	//"encoding/json"
-	"net/http"
 	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
 )
 
-type JSONErrorResponse struct {
-	Message     string    	`json:"message"`
-	Status      int       	`json:"status"`
-	Detail		string		`json:"detail,omitempty"`
-	Issues		[]string  	`json:"issues,omitempty"`
-}
-
-type JSONSuccessResponse struct {
-	Data		interface{}  `json:"data,omitempty"`
-}
-
-type JSONErrorDataResponse struct {
-	Message     string    	`json:"message"`
-	Status      int       	`json:"status"`
-	Detail		string		`json:"detail,omitempty"`
-	Data		interface{}	`json:"data,omitempty"`
-}
+type JSONErrorResponse = respond.JSONErrorResponse
+type JSONSuccessResponse = respond.JSONSuccessResponse
+type JSONErrorDataResponse = respond.JSONErrorDataResponse
+type JSONMultiStatusResponse = respond.JSONMultiStatusResponse
 
 func RESPOK( c *gin.Context ) {
-	c.Status(http.StatusOK)
+	respond.RESPOK( c.Writer )
 }
 
 // Shortcut to respond with a 200 status code
 func JSONOK( c *gin.Context, data interface{} ) {
-	c.JSON(http.StatusOK, data)
+	respond.JSONOK( c.Writer, data )
 }
 
 // Shortcut to respond with a status code and wrapped data
 func JSONData( c *gin.Context, status int, data interface{} ) {
-	resp := JSONSuccessResponse{ Data: data }
-	JSONCode( c, status, resp )
+	respond.JSONData( c.Writer, status, data )
 }
 
 // Format result as an error message response and then send
 // with appropriate error code
 func JSONError( c *gin.Context, status int, message string ) {
-	err := JSONErrorResponse{ Status: status, Message: message }
-	JSONCode( c, status, err )
+	respond.JSONError( c.Writer, status, message )
 }
 
 // Format result as an error message response and then send
 // with appropriate error code
 func JSONErrorWithIssues( c *gin.Context, status int, message string, issues []string ) {
-	err := JSONErrorResponse{ Status: status, Message: message, Issues: issues }
-	JSONCode( c, status, err )
+	respond.JSONErrorWithIssues( c.Writer, status, message, issues )
+}
+
+// Format an error as an error message response, including
+// its issues when the error provides a list of them
+func JSONErrorFromError( c *gin.Context, status int, err error ) {
+	respond.JSONErrorFromError( c.Writer, status, err )
+}
+
+// Format a list of results for each entry in a batch
+// as a multi-status response
+func JSONMultiStatus( c *gin.Context, message string, results interface{} ) {
+	respond.JSONMultiStatus( c.Writer, message, results )
 }
 
 // Format response header and encode interface
 // for standardized json response
 func JSONCode( c *gin.Context, status int, data interface{} ) {
-	c.Header( "Content-Type", "application/json; charset=utf-8" )
-	c.Header( "Access-Control-Allow-Origin", "*" )
-	c.JSON( status, data )
+	respond.JSONCode( c.Writer, status, data )
 }
 
 // Format result as an error message response and then send
 // with appropriate error code and data packet
 func JSONErrorWithData( c *gin.Context, status int, message string, data interface{}, detail string ) {
-	resp := JSONErrorDataResponse{ Status: status, Message: message, Data: data }
-	if detail != "" {
-		resp = JSONErrorDataResponse{ Status: status, Message: message, Detail: detail, Data: data }
-	}
-	JSONCode( c, status, resp )
+	respond.JSONErrorWithData( c.Writer, status, message, data, detail )
 }
 
 // Format Result as an error message response with an additional detail field
 func JSONErrorWithDetail( c *gin.Context, status int, message string, detail string ) {
-	resp := JSONErrorResponse{ Status: status, Message: message }
-	if detail != "" {
-		resp = JSONErrorResponse{ Status: status, Message: message, Detail: detail }
-	}
-	JSONCode( c, status, resp )
+	respond.JSONErrorWithDetail( c.Writer, status, message, detail )
 }
 
 // Shortcut to respond with a status code and wrapped data
 func BYTEOK( c *gin.Context, data []byte ) {
-	BYTECode( c, http.StatusOK, data )
+	respond.BYTEOK( c.Writer, data )
 }
 
 // Shortcut to respond with a status code and wrapped data
 func BYTEData( c *gin.Context, status int, data []byte ) {
-	BYTECode( c, status, data )
+	respond.BYTEData( c.Writer, status, data )
 }
 
 // Output results as Bytes
 func BYTECode( c *gin.Context, status int, data []byte ) {
-	c.Header( "Content-Type", "application/json; charset=utf-8" )
-	c.Header( "Access-Control-Allow-Origin", "*" )
-	c.JSON( status, data )
-}
\ No newline at end of file
+	respond.BYTECode( c.Writer, status, data )
+}
+
+// Output results as Bytes of the given content type
+func BYTECodeWithType( c *gin.Context, status int, contentType string, data []byte ) {
+	respond.BYTECodeWithType( c.Writer, status, contentType, data )
+}
+
+// Render data in the format chosen by respond.NegotiateRenderer,
+// responding with 406 Not Acceptable if none of the formats can be used
+func Negotiate( c *gin.Context, status int, data interface{} ) {
+	respond.Negotiate( c.Writer, c.Request, status, data )
+}
+
+// Render data with the given renderer and send it with its content type
+func Render( c *gin.Context, status int, renderer respond.Renderer, data interface{} ) {
+	respond.Render( c.Writer, status, renderer, data )
+}
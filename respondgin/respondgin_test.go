@@ -0,0 +1,182 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+	"github.com/BioGRID/biogrid-api-common/respond/respondtest"
+	"github.com/BioGRID/biogrid-api-common/respondgin"
+)
+
+type ginResponder struct {
+	c	*gin.Context
+}
+
+func (g ginResponder) RESPOK( ) { respondgin.RESPOK( g.c ) }
+func (g ginResponder) JSONOK( data interface{} ) { respondgin.JSONOK( g.c, data ) }
+func (g ginResponder) JSONData( status int, data interface{} ) { respondgin.JSONData( g.c, status, data ) }
+func (g ginResponder) JSONCode( status int, data interface{} ) { respondgin.JSONCode( g.c, status, data ) }
+func (g ginResponder) JSONError( status int, message string ) { respondgin.JSONError( g.c, status, message ) }
+func (g ginResponder) JSONErrorWithIssues( status int, message string, issues []string ) { respondgin.JSONErrorWithIssues( g.c, status, message, issues ) }
+func (g ginResponder) JSONErrorWithDetail( status int, message string, detail string ) { respondgin.JSONErrorWithDetail( g.c, status, message, detail ) }
+func (g ginResponder) JSONErrorWithData( status int, message string, data interface{}, detail string ) { respondgin.JSONErrorWithData( g.c, status, message, data, detail ) }
+func (g ginResponder) JSONErrorFromError( status int, err error ) { respondgin.JSONErrorFromError( g.c, status, err ) }
+func (g ginResponder) JSONMultiStatus( message string, results interface{} ) { respondgin.JSONMultiStatus( g.c, message, results ) }
+func (g ginResponder) BYTEOK( data []byte ) { respondgin.BYTEOK( g.c, data ) }
+func (g ginResponder) BYTEData( status int, data []byte ) { respondgin.BYTEData( g.c, status, data ) }
+func (g ginResponder) BYTECodeWithType( status int, contentType string, data []byte ) { respondgin.BYTECodeWithType( g.c, status, contentType, data ) }
+func (g ginResponder) Problem( problem respond.ProblemDetails ) { respondgin.Problem( g.c, problem ) }
+func (g ginResponder) Negotiate( status int, data interface{} ) { respondgin.Negotiate( g.c, status, data ) }
+func (g ginResponder) JSONPage( status int, page respond.Page ) { respondgin.JSONPage( g.c, status, page ) }
+func (g ginResponder) JSONCached( status int, data interface{}, policy respond.CachePolicy ) { respondgin.JSONCached( g.c, status, data, policy ) }
+func (g ginResponder) StreamJSONArray( status int, records respond.RecordIterator ) error { return respondgin.StreamJSONArray( g.c, status, records ) }
+func (g ginResponder) StreamNDJSON( status int, records respond.RecordIterator ) error { return respondgin.StreamNDJSON( g.c, status, records ) }
+
+func ginAdapter( handle func( respondtest.Responder ) ) (http.Handler) {
+	engine := gin.New( )
+	engine.Any( "/*path", func( c *gin.Context ) {
+		handle( ginResponder{ c } )
+	})
+	return engine
+}
+
+func TestRespondGin_Conformance( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+	respondtest.Run( t, ginAdapter )
+}
+
+func TestRespondGin_RequestID( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+
+	var contextID, ginID string
+	engine := gin.New( )
+	engine.Use( respondgin.RequestID( ))
+	engine.GET( "/", func( c *gin.Context ) {
+		contextID = respond.RequestID( c.Request.Context( ))
+		ginID = c.GetString( respondgin.RequestIDKey )
+		respondgin.JSONError( c, http.StatusNotFound, "Not found." )
+	})
+
+	r := httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "X-Request-ID", "abc-123" )
+	w := httptest.NewRecorder( )
+	engine.ServeHTTP( w, r )
+
+	assert.Equal( t, "abc-123", contextID )
+	assert.Equal( t, "abc-123", ginID )
+	assert.Equal( t, "abc-123", w.Header( ).Get( "X-Request-ID" ))
+	assert.Equal( t, `{"message":"Not found.","status":404,"requestId":"abc-123"}` + "\n", w.Body.String( ))
+}
+
+func TestRespondGin_CORS( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+
+	engine := gin.New( )
+	policy := &respond.CORSPolicy{ AllowedOrigins: []string{ "https://thebiogrid.org" } }
+	engine.GET( "/open", func( c *gin.Context ) {
+		respondgin.JSONOK( c, "ok" )
+	})
+	engine.GET( "/restricted", respondgin.CORS( policy ), func( c *gin.Context ) {
+		respondgin.JSONOK( c, "ok" )
+	})
+
+	for _, test := range []struct{ path, origin, expected string }{
+		{ "/restricted", "https://thebiogrid.org", "https://thebiogrid.org" },
+		{ "/restricted", "https://example.org", "" },
+		{ "/open", "https://example.org", "*" },
+	} {
+		r := httptest.NewRequest( "GET", test.path, nil )
+		r.Header.Set( "Origin", test.origin )
+		w := httptest.NewRecorder( )
+		engine.ServeHTTP( w, r )
+		assert.Equal( t, test.expected, w.Header( ).Get( "Access-Control-Allow-Origin" ), test.path )
+	}
+}
+
+func TestRespondGin_AccessLog( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+
+	var output bytes.Buffer
+	engine := gin.New( )
+	engine.Use( respondgin.RequestID( ), respondgin.AccessLog( &respond.AccessLogger{ Output: &output } ))
+	engine.GET( "/interactions/:id", func( c *gin.Context ) {
+		respondgin.JSONOK( c, c.Param( "id" ))
+	})
+
+	r := httptest.NewRequest( "GET", "/interactions/103?accessKey=abc", nil )
+	r.Header.Set( "X-Request-ID", "abc-123" )
+	engine.ServeHTTP( httptest.NewRecorder( ), r )
+
+	var entry respond.AccessLogEntry
+	assert.Nil( t, json.Unmarshal( output.Bytes( ), &entry ))
+	assert.Equal( t, "/interactions/103", entry.Path )
+	assert.Equal( t, "/interactions/:id", entry.Route )
+	assert.Equal( t, 200, entry.Status )
+	assert.Equal( t, int64(6), entry.Bytes )
+	assert.Equal( t, "abc-123", entry.RequestID )
+	assert.Equal( t, "sha256:ba7816bf8f01", entry.AccessKey )
+
+	output.Reset( )
+	engine = gin.New( )
+	engine.Use( respondgin.AccessLog( &respond.AccessLogger{ Output: &output } ))
+	engine.GET( "/panic", func( c *gin.Context ) {
+		panic( "failed" )
+	})
+	assert.Panics( t, func( ) { engine.ServeHTTP( httptest.NewRecorder( ), httptest.NewRequest( "GET", "/panic", nil )) } )
+	assert.Nil( t, json.Unmarshal( output.Bytes( ), &entry ))
+	assert.Equal( t, "/panic", entry.Route )
+	assert.Equal( t, http.StatusInternalServerError, entry.Status )
+}
+
+func TestRespondGin_Recover( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+
+	defer func( logger *log.Logger ) { respond.PanicLogger = logger }( respond.PanicLogger )
+	respond.PanicLogger = log.New( io.Discard, "", 0 )
+
+	engine := gin.New( )
+	engine.Use( respondgin.Recover( ))
+	engine.GET( "/", func( c *gin.Context ) {
+		panic( "failed" )
+	})
+
+	w := httptest.NewRecorder( )
+	engine.ServeHTTP( w, httptest.NewRequest( "GET", "/", nil ))
+
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+	assert.Equal( t, `{"message":"An unexpected error occurred while processing the request.","status":500}` + "\n", w.Body.String( ))
+}
+
+func TestRespondGin_RecoverCompress( t *testing.T ) {
+	gin.SetMode( gin.TestMode )
+
+	defer func( logger *log.Logger ) { respond.PanicLogger = logger }( respond.PanicLogger )
+	respond.PanicLogger = log.New( io.Discard, "", 0 )
+
+	engine := gin.New( )
+	engine.Use( respondgin.Recover( ), respondgin.Compress( ))
+	engine.GET( "/", func( c *gin.Context ) {
+		c.Status( http.StatusOK )
+		c.Writer.Write( []byte( "[1," ))
+		panic( "failed" )
+	})
+
+	r := httptest.NewRequest( "GET", "/", nil )
+	r.Header.Set( "Accept-Encoding", "gzip" )
+	w := httptest.NewRecorder( )
+	engine.ServeHTTP( w, r )
+
+	assert.Equal( t, http.StatusInternalServerError, w.Code )
+	assert.Equal( t, `{"message":"An unexpected error occurred while processing the request.","status":500}` + "\n", w.Body.String( ))
+}
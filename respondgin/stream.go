@@ -0,0 +1,26 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package respondgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/BioGRID/biogrid-api-common/respond"
+)
+
+// Stream records as newline delimited json, one record per line.
+// An error from the first record gets a 500 error response, while
+// later errors and client disconnects end the stream early and
+// are returned.
+func StreamNDJSON( c *gin.Context, status int, records respond.RecordIterator ) (error) {
+	return respond.StreamNDJSON( c.Writer, c.Request, status, records )
+}
+
+// Stream records as a single json array. An error from the first
+// record gets a 500 error response, while later errors and client
+// disconnects end the stream early and are returned, leaving the
+// array unclosed so clients can tell the output is incomplete.
+func StreamJSONArray( c *gin.Context, status int, records respond.RecordIterator ) (error) {
+	return respond.StreamJSONArray( c.Writer, c.Request, status, records )
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+var (
+	taxIDRegex      = regexp.MustCompile( `^[1-9][0-9]{0,9}$` )
+	entrezGeneRegex = regexp.MustCompile( `^[1-9][0-9]{0,11}$` )
+	uniprotRegex    = regexp.MustCompile( `^([OPQ][0-9][A-Z0-9]{3}[0-9]|[A-NR-Z][0-9]([A-Z][A-Z0-9]{2}[0-9]){1,2})(-[0-9]+)?$` )
+	ensemblRegex    = regexp.MustCompile( `^ENS([A-Z]{3})?(E|FM|G|GT|P|R|T)[0-9]{11}(\.[0-9]+)?$` )
+	pubmedRegex     = regexp.MustCompile( `^[1-9][0-9]{0,8}$` )
+	doiRegex        = regexp.MustCompile( `^10\.[0-9]{4,9}(\.[0-9]+)*/\S+$` )
+)
+
+// IsTaxID checks for an NCBI taxonomy ID such as 9606
+func IsTaxID( id string ) (bool) {
+	return taxIDRegex.MatchString( id )
+}
+
+// IsEntrezGene checks for an Entrez Gene ID such as 7157
+func IsEntrezGene( id string ) (bool) {
+	return entrezGeneRegex.MatchString( id )
+}
+
+// IsUniProt checks for a UniProt accession such as P04637,
+// optionally with an isoform suffix such as P04637-2
+func IsUniProt( id string ) (bool) {
+	return uniprotRegex.MatchString( id )
+}
+
+// IsEnsembl checks for an Ensembl stable ID such as ENSG00000141510
+// or ENSMUST00000108658.2, with an optional version suffix
+func IsEnsembl( id string ) (bool) {
+	return ensemblRegex.MatchString( id )
+}
+
+// IsPubMed checks for a PubMed ID such as 30476227
+func IsPubMed( id string ) (bool) {
+	return pubmedRegex.MatchString( id )
+}
+
+// IsDOI checks for a DOI such as 10.1093/nar/gky1079
+func IsDOI( id string ) (bool) {
+	return doiRegex.MatchString( strings.TrimPrefix( id, "doi:" ))
+}
+
+// TaxID is the validation function for NCBI taxonomy IDs
+func TaxID( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsTaxID )
+}
+
+// EntrezGene is the validation function for Entrez Gene IDs
+func EntrezGene( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsEntrezGene )
+}
+
+// UniProt is the validation function for UniProt accessions
+func UniProt( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsUniProt )
+}
+
+// Ensembl is the validation function for Ensembl stable IDs
+func Ensembl( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsEnsembl )
+}
+
+// PubMed is the validation function for PubMed IDs
+func PubMed( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsPubMed )
+}
+
+// DOI is the validation function for DOIs
+func DOI( fl validator.FieldLevel ) bool {
+	return matchIdentifier( fl, IsDOI )
+}
+
+// Run an identifier check against the current field, numeric
+// identifiers may be stored as either strings or integers
+func matchIdentifier( fl validator.FieldLevel, isValid func(string) bool ) bool {
+	field := fl.Field()
+
+	switch field.Kind() {
+	case reflect.String:
+		return isValid( field.String() )
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return isValid( strconv.FormatInt( field.Int(), 10 ))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return isValid( strconv.FormatUint( field.Uint(), 10 ))
+	default:
+		return false
+	}
+}
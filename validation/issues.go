@@ -0,0 +1,153 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+var pointerEscaper = strings.NewReplacer( "~", "~0", "/", "~1" )
+
+// Issue is a single validation failure described using
+// the names clients sent rather than the Go field names
+type Issue struct {
+	Path		string		`json:"path"`
+	Field		string		`json:"field"`
+	Rule		string		`json:"rule"`
+	Param		string		`json:"param,omitempty"`
+	Message		string		`json:"message"`
+	Value		interface{}	`json:"value,omitempty"`
+}
+
+// Validate fields and generate a structured issue for
+// each failure, including the JSON pointer to the field
+func (v *ValidationHandler) ValidateStructDetailed( data interface{} ) ([]Issue) {
+	return v.ValidateStructDetailedLocale( data, "" )
+}
+
+// Validate fields and generate structured issues with messages
+// in the locale best matching an Accept-Language header
+func (v *ValidationHandler) ValidateStructDetailedLocale( data interface{}, acceptLanguage string ) ([]Issue) {
+
+	var issues []Issue
+
+	err := v.Validate.Struct(data)
+	if err != nil {
+		issues = v.formatValidationIssues( err, structName( data ), v.FindTranslator( acceptLanguage ))
+	}
+
+	return issues
+}
+
+// Convert validation errors into structured issues, stripping
+// the top level struct name from each namespace
+func (v *ValidationHandler) formatValidationIssues( err error, topName string, trans ut.Translator ) ([]Issue) {
+
+	var issues []Issue
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []Issue{ { Path: "", Rule: "invalid", Message: err.Error( ) } }
+	}
+
+	for _, err := range validationErrors {
+		namespace := err.Namespace( )
+		if topName != "" {
+			namespace = strings.TrimPrefix( namespace, topName + "." )
+		}
+
+		issues = append( issues, Issue{
+			Path: JSONPointer( namespace ),
+			Field: err.Field( ),
+			Rule: err.Tag( ),
+			Param: err.Param( ),
+			Message: v.translateValidationError( err, trans ),
+			Value: err.Value( ),
+		})
+	}
+
+	return issues
+}
+
+// Messages returns only the human readable message
+// from each of a list of issues
+func Messages( issues []Issue ) ([]string) {
+	var messages []string
+	for _, issue := range issues {
+		messages = append( messages, issue.Message )
+	}
+
+	return messages
+}
+
+// JSONPointer converts a validator namespace such as
+// interactors[2].taxId into an RFC 6901 pointer such as
+// /interactors/2/taxId
+func JSONPointer( namespace string ) (string) {
+	if namespace == "" {
+		return ""
+	}
+
+	var pointer strings.Builder
+	var token strings.Builder
+	inIndex := false
+
+	flush := func( ) {
+		pointer.WriteString( "/" )
+		pointer.WriteString( pointerEscaper.Replace( token.String( )))
+		token.Reset( )
+	}
+
+	for _, c := range namespace {
+		switch {
+		case (c == '.' || c == '[') && !inIndex :
+			// A field following an index has nothing to flush
+			if token.Len( ) > 0 {
+				flush( )
+			}
+			inIndex = c == '['
+		case c == ']' && inIndex :
+			flush( )
+			inIndex = false
+		default :
+			token.WriteRune( c )
+		}
+	}
+
+	if token.Len( ) > 0 {
+		flush( )
+	}
+
+	return pointer.String( )
+}
+
+// Use the name from the json tag when reporting a field,
+// falling back to the Go field name when there is none
+func jsonTagName( fld reflect.StructField ) (string) {
+	name := strings.SplitN( fld.Tag.Get( "json" ), ",", 2 )[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// Find the name the validator uses as the first
+// segment of the namespace for this value
+func structName( data interface{} ) (string) {
+	t := reflect.TypeOf( data )
+	for t != nil && t.Kind( ) == reflect.Ptr {
+		t = t.Elem( )
+	}
+
+	if t == nil {
+		return ""
+	}
+
+	return t.Name( )
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// Message placeholders available to templates
+const (
+	FieldPlaceholder = "{field}"
+	ParamPlaceholder = "{param}"
+	ValuePlaceholder = "{value}"
+)
+
+// Tag used to look up the message for rules
+// without a template of their own
+const defaultMessageTag = "default"
+
+// English messages registered for every handler
+var defaultMessages = map[string]string{
+	"required" : "{field} is a required field and cannot be empty",
+	"ascii" : "{field} can contain only ascii characters",
+	"printascii" : "{field} can contain only printable ascii characters",
+	"email" : "{field} must be a valid email field",
+	"len" : "{field} must be of length {param}",
+	"min" : "{field} must be greater than or equal to {param} or at least {param} in length if a string",
+	"max" : "{field} must be less than or equal to {param} or at most {param} in length if a string",
+	"oneof" : "{field} must be one of the following values: {param}",
+	"alphanum" : "{field} must consist of only letters of the alphabet or numbers",
+	"notblank" : "{field} cannot be blank. That includes empty arrays and strings of only whitespace.",
+	"alpha" : "{field} must contain only ascii alpha characters.",
+	"url" : "{field} must be a valid URL and must include the schema such as http:// or ftp:// or https:// etc.",
+	"taxid" : "{field} must be a valid NCBI taxonomy ID such as 9606",
+	"entrezgene" : "{field} must be a valid Entrez Gene ID such as 7157",
+	"uniprot" : "{field} must be a valid UniProt accession such as P04637",
+	"ensembl" : "{field} must be a valid Ensembl stable ID such as ENSG00000141510",
+	"pubmed" : "{field} must be a valid PubMed ID such as 30476227",
+	"doi" : "{field} must be a valid DOI such as 10.1093/nar/gky1079",
+	defaultMessageTag : "{field} is not validly formatted",
+}
+
+// Setup the translator with English as the fallback
+// locale and load the default messages into it
+func (v *ValidationHandler) initializeMessages( ) {
+	english := en.New( )
+	v.Translator = ut.New( english, english )
+	v.messageParams = map[string]map[string][]string{}
+
+	for tag, template := range defaultMessages {
+		v.RegisterMessage( english.Locale( ), tag, template )
+	}
+}
+
+// AddLocale makes a new locale available for messages, such as
+// fr.New( ) from github.com/go-playground/locales/fr. Tags without
+// a message in that locale fall back to the English message.
+func (v *ValidationHandler) AddLocale( locale locales.Translator ) (error) {
+	return v.Translator.AddTranslator( locale, false )
+}
+
+// RegisterMessage sets the message template used for a validation
+// tag in a locale, replacing any existing template. Templates may use
+// {field}, {param} and {value} any number of times. Register a template
+// for the tag "default" to change the message for unknown tags.
+func (v *ValidationHandler) RegisterMessage( locale, tag, template string ) (error) {
+	tag = strings.ToLower(tag)
+	trans, found := v.Translator.GetTranslator( locale )
+	if !found {
+		return errors.New( "Locale " + locale + " has not been added to the validation handler" )
+	}
+
+	// The translator only supports numbered parameters in order
+	// so number each placeholder and remember what it refers to
+	var params []string
+	var text strings.Builder
+	rest := template
+	for {
+		start := strings.Index( rest, "{" )
+		if start == -1 {
+			text.WriteString( rest )
+			break
+		}
+
+		end := strings.Index( rest[start:], "}" )
+		if end == -1 {
+			return errors.New( "Message for " + tag + " has an unclosed placeholder" )
+		}
+
+		placeholder := rest[start:start+end+1]
+		switch placeholder {
+		case FieldPlaceholder, ParamPlaceholder, ValuePlaceholder :
+		default :
+			return errors.New( "Message for " + tag + " has an unknown placeholder " + placeholder )
+		}
+
+		text.WriteString( rest[:start] )
+		text.WriteString( "{" + strconv.Itoa( len(params) ) + "}" )
+		params = append( params, placeholder )
+		rest = rest[start+end+1:]
+	}
+
+	err := trans.Add( tag, text.String( ), true )
+	if err != nil {
+		return err
+	}
+
+	localeKey := strings.ToLower( trans.Locale( ))
+	if v.messageParams[localeKey] == nil {
+		v.messageParams[localeKey] = map[string][]string{}
+	}
+	v.messageParams[localeKey][tag] = params
+
+	return nil
+}
+
+// FindTranslator picks the best available locale for an
+// Accept-Language header, or the fallback if none match
+func (v *ValidationHandler) FindTranslator( acceptLanguage string ) (ut.Translator) {
+	trans, _ := v.Translator.FindTranslator( ParseAcceptLanguage( acceptLanguage )... )
+	return trans
+}
+
+// Render the message for a failed field, trying the tag in the
+// chosen locale, then in the fallback locale, then the same for the
+// tag an alias expanded to, then the default message
+func (v *ValidationHandler) translateValidationError( err validator.FieldError, trans ut.Translator ) (string) {
+	values := map[string]string{
+		FieldPlaceholder : err.Field( ),
+		ParamPlaceholder : err.Param( ),
+		ValuePlaceholder : fmt.Sprint( err.Value( )),
+	}
+
+	fallback := v.Translator.GetFallback( )
+	tags := []string{ strings.ToLower( err.Tag( )), strings.ToLower( err.ActualTag( )), defaultMessageTag }
+	for _, key := range tags {
+		for _, t := range []ut.Translator{ trans, fallback } {
+			params, ok := v.messageParams[strings.ToLower( t.Locale( ))][key]
+			if !ok {
+				continue
+			}
+
+			args := make( []string, len(params) )
+			for i, param := range params {
+				args[i] = values[param]
+			}
+
+			if message, err := t.T( key, args... ); err == nil {
+				return message
+			}
+		}
+	}
+
+	return err.Field( ) + " is not validly formatted"
+}
+
+// ParseAcceptLanguage orders the locales in an Accept-Language header
+// by preference, in the form used by the locales package such as fr_CA,
+// with each regional locale followed by its base language
+func ParseAcceptLanguage( header string ) ([]string) {
+	type weighted struct {
+		locale	string
+		q		float64
+	}
+
+	var languages []weighted
+	for _, part := range strings.Split( header, "," ) {
+		fields := strings.Split( strings.TrimSpace(part), ";" )
+		tag := strings.TrimSpace( fields[0] )
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix( param, "q=" ) {
+				if parsed, err := strconv.ParseFloat( param[2:], 64 ); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q > 0 {
+			languages = append( languages, weighted{ tag, q } )
+		}
+	}
+
+	sort.SliceStable( languages, func( i, j int ) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	var result []string
+	for _, language := range languages {
+		subtags := strings.Split( strings.Replace( language.locale, "-", "_", -1 ), "_" )
+		base := strings.ToLower( subtags[0] )
+		if len(subtags) > 1 {
+			result = append( result, base + "_" + strings.ToUpper( subtags[1] ))
+		}
+		result = append( result, base )
+	}
+
+	return result
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 BioGRID Project. All rights reserved.
+// Use of this source code is governed by the MIT License
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// StructCheck tests a whole struct, which is passed by value
+// rather than as a pointer, and returns false if the rule is broken
+type StructCheck func( data interface{} ) bool
+
+type structRule struct {
+	tag		string
+	field	string
+	check	StructCheck
+}
+
+// RegisterRule adds a field validation tag along with the
+// message template used in the fallback locale when it fails
+func (v *ValidationHandler) RegisterRule( tag string, fn validator.Func, message string ) (error) {
+	err := v.Validate.RegisterValidation( tag, fn )
+	if err != nil {
+		return err
+	}
+
+	return v.registerFallbackMessage( tag, message )
+}
+
+// RegisterAlias adds a tag that expands into a list of other tags,
+// such as "geneid" for "required,entrezgene". Failures are reported
+// using the alias message when one is given, otherwise using the
+// message for whichever of the expanded tags failed.
+func (v *ValidationHandler) RegisterAlias( alias, tags, message string ) (error) {
+	if alias == "" || tags == "" {
+		return errors.New( "An alias requires both a name and a list of tags" )
+	}
+
+	v.Validate.RegisterAlias( alias, tags )
+	return v.registerFallbackMessage( alias, message )
+}
+
+// RegisterStructRule adds a cross field check for each of the given
+// struct types, such as requiring that one of two lists is set. When the
+// check fails an issue with the given tag is reported against field, which
+// is the json name of the field or blank to report against the struct itself.
+// Any number of rules may be registered for the same type.
+func (v *ValidationHandler) RegisterStructRule( tag, field string, check StructCheck, message string, types ...interface{} ) (error) {
+	if tag == "" || check == nil || len(types) == 0 {
+		return errors.New( "A struct rule requires a tag, a check and at least one type" )
+	}
+
+	// Check every type before registering any so
+	// a bad entry leaves nothing half registered
+	structTypes := make( []reflect.Type, len(types) )
+	for i, t := range types {
+		typ := reflect.TypeOf( t )
+		for typ != nil && typ.Kind( ) == reflect.Ptr {
+			typ = typ.Elem( )
+		}
+		if typ == nil || typ.Kind( ) != reflect.Struct {
+			return errors.New( "A struct rule can only be registered for struct types" )
+		}
+		structTypes[i] = typ
+	}
+
+	if v.structRules == nil {
+		v.structRules = map[reflect.Type][]structRule{}
+	}
+
+	rule := structRule{ tag: tag, field: field, check: check }
+	for i, t := range types {
+		typ := structTypes[i]
+
+		// The validator keeps one function per type, so register
+		// it once and have it run every rule for that type
+		if _, exists := v.structRules[typ]; !exists {
+			v.Validate.RegisterStructValidation( v.runStructRules, t )
+		}
+		v.structRules[typ] = append( v.structRules[typ], rule )
+	}
+
+	return v.registerFallbackMessage( tag, message )
+}
+
+// Run every struct rule registered for the current type
+// and report an error for each one that fails
+func (v *ValidationHandler) runStructRules( sl validator.StructLevel ) {
+	current := sl.Current( )
+	for _, rule := range v.structRules[current.Type( )] {
+		if rule.check( current.Interface( )) {
+			continue
+		}
+
+		value, structFieldName := structFieldByName( current, rule.field )
+		sl.ReportError( value, rule.field, structFieldName, rule.tag, "" )
+	}
+}
+
+// Add a message in the fallback locale, an empty
+// message leaves the current one in place
+func (v *ValidationHandler) registerFallbackMessage( tag, message string ) (error) {
+	if message == "" {
+		return nil
+	}
+
+	return v.RegisterMessage( v.Translator.GetFallback( ).Locale( ), tag, message )
+}
+
+// Find a field on a struct by json name, or by Go name
+// when it has no json name, returning its value and Go name
+func structFieldByName( current reflect.Value, name string ) (interface{}, string) {
+	if name == "" {
+		return nil, ""
+	}
+
+	typ := current.Type( )
+	for i := 0; i < typ.NumField( ); i++ {
+		fld := typ.Field( i )
+		if fld.PkgPath != "" {
+			continue
+		}
+
+		if jsonTagName( fld ) == name || (jsonTagName( fld ) == "" && fld.Name == name) {
+			return current.Field( i ).Interface( ), fld.Name
+		}
+	}
+
+	return nil, name
+}
@@ -7,28 +7,46 @@ package validation
 import (
 	"strings"
 	"reflect"
+	"github.com/go-playground/universal-translator"
 	"gopkg.in/go-playground/validator.v9"
 )
 
 type ValidationHandler struct {
 	Validate *validator.Validate
+	Translator *ut.UniversalTranslator
+	messageParams map[string]map[string][]string
+	structRules map[reflect.Type][]structRule
 }
 
 // Initialize Validator
 func (v *ValidationHandler) Initialize( ) {
 	v.Validate = validator.New( )
+	v.Validate.RegisterTagNameFunc( jsonTagName )
 	v.Validate.RegisterValidation( "notblank", NotBlank )
+	v.Validate.RegisterValidation( "taxid", TaxID )
+	v.Validate.RegisterValidation( "entrezgene", EntrezGene )
+	v.Validate.RegisterValidation( "uniprot", UniProt )
+	v.Validate.RegisterValidation( "ensembl", Ensembl )
+	v.Validate.RegisterValidation( "pubmed", PubMed )
+	v.Validate.RegisterValidation( "doi", DOI )
+	v.initializeMessages( )
 }
 
 // Validate fields here and generate messages
 // that can be incorporated into output later on
 func (v *ValidationHandler) ValidateStruct( data interface{} ) ([]string) {
+	return v.ValidateStructLocale( data, "" )
+}
+
+// Validate fields and generate messages in the locale best
+// matching an Accept-Language header
+func (v *ValidationHandler) ValidateStructLocale( data interface{}, acceptLanguage string ) ([]string) {
 
 	var issues []string
 
 	err := v.Validate.Struct(data)
 	if err != nil {
-		issues = v.formatValidationErrors( err )
+		issues = v.formatValidationErrors( err, v.FindTranslator( acceptLanguage ))
 	}  
 
 	return issues
@@ -36,64 +54,17 @@ func (v *ValidationHandler) ValidateStruct( data interface{} ) ([]string) {
 
 // Create easy to read field errors for failed validation
 // for output to the user
-func (v *ValidationHandler) formatValidationErrors( err error ) ([]string) {
+func (v *ValidationHandler) formatValidationErrors( err error, trans ut.Translator ) ([]string) {
 
 	var errors []string
 
 	for _, err := range err.(validator.ValidationErrors) {		
-		errors = append( errors, v.formatValidationError( err ))	
+		errors = append( errors, v.translateValidationError( err, trans ))	
 	}
 
 	return errors
 } 
 
-// Format each field slightly differently depending on 
-// the type of error it is
-func (v *ValidationHandler) formatValidationError( err validator.FieldError ) (string) {
-	switch strings.ToLower(err.Tag()) {
-	
-	case "required" :
-		return err.Field( ) + " is a required field and cannot be empty"
-	
-	case "ascii" :
-		return err.Field( ) + " can contain only ascii characters"
-
-	case "printascii" :
-		return err.Field( ) + " can contain only printable ascii characters"
-
-	case "email" :
-		return err.Field( ) + " must be a valid email field"
-
-	case "len" :
-		return err.Field( ) + " must be of length " + err.Param( )
-
-	case "min" :
-		return err.Field( ) + " must be greater than or equal to " + err.Param( ) + " or at least " + err.Param( ) + " in length if a string"
-
-	case "max" :
-		return err.Field( ) + " must be less than or equal to " + err.Param( ) + " or at most " + err.Param( ) + " in length if a string"
-
-	case "oneof" :
-		return err.Field( ) + " must be one of the following values: " + err.Param( )
-
-	case "alphanum" :
-		return err.Field( ) + " must consist of only letters of the alphabet or numbers"
-
-	case "notblank" :
-		return err.Field( ) + " cannot be blank. That includes empty arrays and strings of only whitespace."
-
-	case "alpha" :
-		return err.Field( ) + " must contain only ascii alpha characaters."
-
-	case "url" :
-		return err.Field( ) + " must be a valid URL and must include the schema such as http:// or ftp:// or https:// etc."
-
-	default :
-		return err.Field( ) + " is not validly formatted"
-
-	}
-}
-
 // NotBlank is the validation function for validating if the current field
 // has a value or length greater than zero, or is not a space only string.
 func NotBlank(fl validator.FieldLevel) bool {
@@ -5,10 +5,13 @@
 package validation_test
 
 import (
+	"strings"
 	"testing"
+	"github.com/go-playground/locales/fr"
 	"github.com/stretchr/testify/assert"
 	"github.com/BioGRID/biogrid-api-common/testutils"
 	"github.com/BioGRID/biogrid-api-common/validation"
+	"gopkg.in/go-playground/validator.v9"
 )
 
 var vh validation.ValidationHandler
@@ -299,4 +302,272 @@ func TestValidate_NotBlank( t *testing.T ) {
 	issues = vh.ValidateStruct( &va )
 	assert.Equal( t, 0, len(issues))
 
-}
\ No newline at end of file
+}
+
+func TestValidate_Identifiers( t *testing.T ) {
+	var v = struct {
+		TaxID      string `validate:"omitempty,taxid"`
+		EntrezGene string `validate:"omitempty,entrezgene"`
+		UniProt    string `validate:"omitempty,uniprot"`
+		Ensembl    string `validate:"omitempty,ensembl"`
+		PubMed     string `validate:"omitempty,pubmed"`
+		DOI        string `validate:"omitempty,doi"`
+	}{ }
+
+	var tests = []struct{
+		tag     string
+		param   string
+		expected int
+	} {
+		{"taxid","9606",0},
+		{"taxid","559292",0},
+		{"taxid","0",1},
+		{"taxid","-9606",1},
+		{"taxid","human",1},
+		{"entrezgene","7157",0},
+		{"entrezgene","07157",1},
+		{"entrezgene","TP53",1},
+		{"uniprot","P04637",0},
+		{"uniprot","P04637-2",0},
+		{"uniprot","A0A023GPI8",0},
+		{"uniprot","p04637",1},
+		{"uniprot","P0463",1},
+		{"ensembl","ENSG00000141510",0},
+		{"ensembl","ENSMUST00000108658.2",0},
+		{"ensembl","ENSG0000014151",1},
+		{"ensembl","ENSX00000141510",1},
+		{"pubmed","30476227",0},
+		{"pubmed","PMID:30476227",1},
+		{"pubmed","1234567890",1},
+		{"doi","10.1093/nar/gky1079",0},
+		{"doi","doi:10.1093/nar/gky1079",0},
+		{"doi","10.1093",1},
+		{"doi","nar/gky1079",1},
+	}
+
+	var issues []string
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.tag + " " + test.param )
+		v.TaxID, v.EntrezGene, v.UniProt, v.Ensembl, v.PubMed, v.DOI = "", "", "", "", "", ""
+		switch test.tag {
+		case "taxid" : v.TaxID = test.param
+		case "entrezgene" : v.EntrezGene = test.param
+		case "uniprot" : v.UniProt = test.param
+		case "ensembl" : v.Ensembl = test.param
+		case "pubmed" : v.PubMed = test.param
+		case "doi" : v.DOI = test.param
+		}
+		issues = vh.ValidateStruct( &v )
+		assert.Equal( t, test.expected, len(issues))
+	}
+}
+
+func TestValidate_NumericIdentifiers( t *testing.T ) {
+	var v = struct {
+		TaxID  uint64 `validate:"taxid"`
+		Genes  []int  `validate:"dive,entrezgene"`
+	}{ TaxID: 9606, Genes: []int{ 7157, 672 } }
+
+	issues := vh.ValidateStruct( &v )
+	assert.Equal( t, 0, len(issues))
+
+	v.TaxID = 0
+	v.Genes = []int{ 7157, -1 }
+	issues = vh.ValidateStruct( &v )
+	assert.Equal( t, 2, len(issues))
+	assert.Equal( t, "TaxID must be a valid NCBI taxonomy ID such as 9606", issues[0] )
+}
+
+type detailedInteractor struct {
+	Symbol string `json:"symbol" validate:"required"`
+	TaxID  uint64 `json:"taxId" validate:"taxid"`
+}
+
+type detailedReq struct {
+	Name        string               `json:"name" validate:"min=3"`
+	Interactors []detailedInteractor `json:"interactors" validate:"dive"`
+	Meta        map[string]string    `json:"meta" validate:"dive,notblank"`
+	Hidden      string               `json:"-" validate:"required"`
+}
+
+func TestValidate_StructDetailed( t *testing.T ) {
+	var v = detailedReq{
+		Name: "ab",
+		Interactors: []detailedInteractor{
+			{ Symbol: "TP53", TaxID: 9606 },
+			{ Symbol: "", TaxID: 9606 },
+			{ Symbol: "MDM2", TaxID: 0 },
+		},
+		Meta: map[string]string{ "a/b": " " },
+		Hidden: "set",
+	}
+
+	issues := vh.ValidateStructDetailed( &v )
+	assert.Equal( t, 4, len(issues))
+
+	assert.Equal( t, validation.Issue{
+		Path: "/name", Field: "name", Rule: "min", Param: "3",
+		Message: "name must be greater than or equal to 3 or at least 3 in length if a string",
+		Value: "ab",
+	}, issues[0] )
+	assert.Equal( t, "/interactors/1/symbol", issues[1].Path )
+	assert.Equal( t, "symbol", issues[1].Field )
+	assert.Equal( t, "required", issues[1].Rule )
+	assert.Equal( t, "/interactors/2/taxId", issues[2].Path )
+	assert.Equal( t, uint64(0), issues[2].Value )
+	assert.Equal( t, "/meta/a~1b", issues[3].Path )
+
+	v.Hidden = ""
+	issues = vh.ValidateStructDetailed( v )
+	assert.Equal( t, 5, len(issues))
+	assert.Equal( t, "/Hidden", issues[4].Path )
+
+	assert.Equal( t, vh.ValidateStruct( &v ), validation.Messages( issues ))
+}
+
+func TestValidate_JSONPointer( t *testing.T ) {
+	var tests = []struct{
+		param    string
+		expected string
+	} {
+		{"",""},
+		{"name","/name"},
+		{"interactors[2].taxId","/interactors/2/taxId"},
+		{"matrix[1][0]","/matrix/1/0"},
+		{"meta[a.b]","/meta/a.b"},
+		{"meta[~x]","/meta/~0x"},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.param )
+		assert.Equal( t, test.expected, validation.JSONPointer( test.param ))
+	}
+}
+
+func TestValidate_MessageTemplates( t *testing.T ) {
+	var mh validation.ValidationHandler
+	mh.Initialize( )
+
+	var v = struct {
+		Name  string `json:"name" validate:"min=3"`
+		Genes []int  `json:"genes" validate:"dive,entrezgene"`
+		Alpha string `json:"alpha" validate:"alpha"`
+	}{ Name: "ab", Genes: []int{ -1 }, Alpha: "a1" }
+
+	issues := mh.ValidateStruct( &v )
+	assert.Equal( t, []string{
+		"name must be greater than or equal to 3 or at least 3 in length if a string",
+		"genes[0] must be a valid Entrez Gene ID such as 7157",
+		"alpha must contain only ascii alpha characters.",
+	}, issues )
+
+	assert.Nil( t, mh.RegisterMessage( "en", "min", "{field} needs {param}, got {value}" ))
+	assert.Nil( t, mh.RegisterMessage( "en", "default", "{field} is wrong" ))
+	assert.NotNil( t, mh.RegisterMessage( "en", "min", "{field} needs {other}" ))
+	assert.NotNil( t, mh.RegisterMessage( "en", "min", "{field needs" ))
+	assert.NotNil( t, mh.RegisterMessage( "fr", "min", "{field} est trop court" ))
+
+	assert.Nil( t, mh.AddLocale( fr.New( )))
+	assert.Nil( t, mh.RegisterMessage( "fr", "min", "{field} doit contenir au moins {param} caractères" ))
+
+	var w = struct {
+		Name string `json:"name" validate:"min=3"`
+		Mail string `json:"mail" validate:"email"`
+	}{ Name: "ab", Mail: "x" }
+
+	assert.Equal( t, []string{ "name needs 3, got ab", "mail must be a valid email field" }, mh.ValidateStruct( &w ))
+	assert.Equal( t, []string{ "name doit contenir au moins 3 caractères", "mail must be a valid email field" }, mh.ValidateStructLocale( &w, "fr-CA,fr;q=0.9,en;q=0.5" ))
+	assert.Equal( t, []string{ "name needs 3, got ab", "mail must be a valid email field" }, mh.ValidateStructLocale( &w, "de" ))
+
+	detailed := mh.ValidateStructDetailedLocale( &w, "fr" )
+	assert.Equal( t, "name doit contenir au moins 3 caractères", detailed[0].Message )
+
+	var u = struct {
+		Name string `json:"name" validate:"hexcolor"`
+	}{ Name: "x" }
+	assert.Equal( t, []string{ "name is wrong" }, mh.ValidateStructLocale( &u, "fr" ))
+}
+
+func TestValidate_ParseAcceptLanguage( t *testing.T ) {
+	var tests = []struct{
+		param    string
+		expected []string
+	} {
+		{"",nil},
+		{"en",[]string{ "en" }},
+		{"fr-ca,en;q=0.8",[]string{ "fr_CA", "fr", "en" }},
+		{"en;q=0.2, de;q=0.9, *;q=0.1",[]string{ "de", "en" }},
+		{"es;q=0, pt-BR",[]string{ "pt_BR", "pt" }},
+	}
+
+	for _,test := range tests {
+		testutils.OutputTestNote( t, test.param )
+		assert.Equal( t, test.expected, validation.ParseAcceptLanguage( test.param ))
+	}
+}
+
+type ruleRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type ruleReq struct {
+	GeneList   []string  `json:"geneList" validate:"dive,geneid"`
+	PubmedList []string  `json:"pubmedList"`
+	Symbol     string    `json:"symbol" validate:"omitempty,symbol"`
+	Range      ruleRange `json:"range"`
+}
+
+func TestValidate_CustomRules( t *testing.T ) {
+	var rh validation.ValidationHandler
+	rh.Initialize( )
+
+	assert.Nil( t, rh.RegisterRule( "symbol", func( fl validator.FieldLevel ) bool {
+		return fl.Field( ).String( ) == strings.ToUpper( fl.Field( ).String( ))
+	}, "{field} must be an upper case symbol, not {value}" ))
+	assert.Nil( t, rh.RegisterAlias( "geneid", "required,entrezgene", "" ))
+	assert.NotNil( t, rh.RegisterAlias( "", "required", "" ))
+
+	assert.Nil( t, rh.RegisterStructRule( "genesorpubmed", "", func( data interface{} ) bool {
+		req := data.(ruleReq)
+		return len(req.GeneList) > 0 || len(req.PubmedList) > 0
+	}, "either geneList or pubmedList must be set", ruleReq{} ))
+	assert.Nil( t, rh.RegisterStructRule( "startend", "end", func( data interface{} ) bool {
+		r := data.(ruleRange)
+		return r.Start <= r.End
+	}, "{field} must not be before start", &ruleRange{} ))
+	assert.Nil( t, rh.RegisterStructRule( "nosymbolwithpubmed", "symbol", func( data interface{} ) bool {
+		req := data.(ruleReq)
+		return req.Symbol == "" || len(req.PubmedList) == 0
+	}, "{field} cannot be combined with pubmedList", ruleReq{} ))
+	assert.NotNil( t, rh.RegisterStructRule( "nocheck", "", nil, "", ruleReq{} ))
+	assert.NotNil( t, rh.RegisterStructRule( "niltype", "", func( data interface{} ) bool { return true }, "", nil ))
+	assert.NotNil( t, rh.RegisterStructRule( "notstruct", "", func( data interface{} ) bool { return true }, "", "text" ))
+
+	v := ruleReq{ GeneList: []string{ "7157" }, Range: ruleRange{ 1, 5 } }
+	assert.Equal( t, 0, len(rh.ValidateStructDetailed( &v )))
+
+	v = ruleReq{ Symbol: "tp53", Range: ruleRange{ 5, 1 } }
+	issues := rh.ValidateStructDetailed( &v )
+	assert.Equal( t, 3, len(issues))
+	assert.Equal( t, "symbol must be an upper case symbol, not tp53", issues[0].Message )
+	assert.Equal( t, "/range/end", issues[1].Path )
+	assert.Equal( t, "end must not be before start", issues[1].Message )
+	assert.Equal( t, 1, issues[1].Value )
+	assert.Equal( t, "", issues[2].Path )
+	assert.Equal( t, "either geneList or pubmedList must be set", issues[2].Message )
+
+	v = ruleReq{ PubmedList: []string{ "1" }, Symbol: "TP53" }
+	issues = rh.ValidateStructDetailed( &v )
+	assert.Equal( t, 1, len(issues))
+	assert.Equal( t, "/symbol", issues[0].Path )
+	assert.Equal( t, "symbol cannot be combined with pubmedList", issues[0].Message )
+	assert.Equal( t, "TP53", issues[0].Value )
+
+	v = ruleReq{ GeneList: []string{ "7157", "", "TP53" } }
+	issues = rh.ValidateStructDetailed( &v )
+	assert.Equal( t, 2, len(issues))
+	assert.Equal( t, "geneid", issues[0].Rule )
+	assert.Equal( t, "geneList[1] is a required field and cannot be empty", issues[0].Message )
+	assert.Equal( t, "geneList[2] must be a valid Entrez Gene ID such as 7157", issues[1].Message )
+}